@@ -0,0 +1,106 @@
+// Generate the favorite music badge
+package badge
+
+import (
+	"fmt"
+	"strings"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+// How the badge is rendered
+const (
+	// Link to img.shields.io
+	ShieldsRenderer = "shields"
+	// Svg file generated locally by Generate_svg
+	SvgRenderer = "svg"
+)
+
+// Default name of the svg badge
+const SVG_FILENAME = "favorite_music_badge.svg"
+
+// Transform an string to make it safe within URL's
+func safeUrl(str string) string {
+	str = strings.ReplaceAll(str, "?", "%3F")
+	str = strings.ReplaceAll(str, "\"", "%22")
+	str = strings.ReplaceAll(str, " ", "%20")
+	str = strings.ReplaceAll(str, "&", "%26")
+	str = strings.ReplaceAll(str, "=", "%3D")
+	str = strings.ReplaceAll(str, "#", "%23")
+	str = strings.ReplaceAll(str, "\\", "%5C")
+	str = strings.ReplaceAll(str, "-", "–")
+	return str
+}
+
+// How the badge looks, every field is the same as in https://shields.io/badges
+//
+// Style, Logo, LogoColor, LogoSize, LabelColor, Color, and CacheSeconds are all optional
+// and will be omitted if they are set to the empty string, they are added directly to the badge creator.
+//
+// MessageColor is special, if it is empty, it will be set to mistyrose
+type Options struct {
+	MessageColor string
+	Style        string
+	Logo         string
+	LogoColor    string
+	LogoSize     string
+	LabelColor   string
+	Color        string
+	CacheSeconds string
+}
+
+// Text on the left side of the badge, the rank is added when the song is part of a list
+func Label(song providers.Song) string {
+	if song.NowPlaying {
+		return "Now playing"
+	}
+
+	label := "Favorite music"
+	if song.Recent {
+		label = "Last played"
+	} else if song.Entity == providers.EntityArtist {
+		label = "Favorite artist"
+	} else if song.Entity == providers.EntityAlbum {
+		label = "Favorite album"
+	}
+
+	if song.Rank > 0 {
+		return fmt.Sprintf("%v #%v", label, song.Rank)
+	}
+	return label
+}
+
+// Text on the right side of the badge
+func Message(song providers.Song) string {
+	switch song.Entity {
+	case providers.EntityArtist:
+		return song.Author()
+	case providers.EntityAlbum:
+		return fmt.Sprintf("%v by %v", song.Album, song.Author())
+	}
+	return fmt.Sprintf("%v by %v", song.Title, song.Author())
+}
+
+// Generate an image link from a song
+func Generate_image_link(song providers.Song, options Options) (link string) {
+	message_color := options.MessageColor
+	if message_color == "" {
+		message_color = "mistyrose"
+	}
+
+	query := ""
+	for _, parameter := range []struct{ key, value string }{
+		{"style", options.Style},
+		{"logo", options.Logo},
+		{"logoColor", options.LogoColor},
+		{"logoSize", options.LogoSize},
+		{"labelColor", options.LabelColor},
+		{"color", options.Color},
+		{"cacheSeconds", options.CacheSeconds},
+	} {
+		if parameter.value != "" {
+			query += fmt.Sprintf("%v=%v&", parameter.key, parameter.value)
+		}
+	}
+	return fmt.Sprintf("https://img.shields.io/badge/%v-%v-%v?%v", safeUrl(Label(song)), safeUrl(Message(song)), message_color, query)
+}
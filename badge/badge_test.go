@@ -0,0 +1,61 @@
+package badge
+
+import (
+	"testing"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+func TestLabelAndMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		song    providers.Song
+		label   string
+		message string
+	}{
+		{
+			name:    "track",
+			song:    providers.Song{Entity: providers.EntityTrack, Title: "One", Artists: []string{"A", "B"}, Album: "First"},
+			label:   "Favorite music",
+			message: "One by A, B",
+		},
+		{
+			name:    "artist",
+			song:    providers.Song{Entity: providers.EntityArtist, Artists: []string{"A"}},
+			label:   "Favorite artist",
+			message: "A",
+		},
+		{
+			name:    "album",
+			song:    providers.Song{Entity: providers.EntityAlbum, Artists: []string{"A"}, Album: "First"},
+			label:   "Favorite album",
+			message: "First by A",
+		},
+		{
+			name:    "ranked album",
+			song:    providers.Song{Entity: providers.EntityAlbum, Artists: []string{"A"}, Album: "First", Rank: 2},
+			label:   "Favorite album #2",
+			message: "First by A",
+		},
+		{
+			name:    "last played",
+			song:    providers.Song{Entity: providers.EntityTrack, Title: "One", Artists: []string{"A"}, Recent: true},
+			label:   "Last played",
+			message: "One by A",
+		},
+		{
+			name:    "now playing",
+			song:    providers.Song{Entity: providers.EntityTrack, Title: "One", Artists: []string{"A"}, Recent: true, NowPlaying: true, Rank: 1},
+			label:   "Now playing",
+			message: "One by A",
+		},
+	}
+	for _, test := range tests {
+		if label := Label(test.song); label != test.label {
+			t.Errorf("%v: got the label %q, want %q", test.name, label, test.label)
+		}
+		if message := Message(test.song); message != test.message {
+			t.Errorf("%v: got the message %q, want %q", test.name, message, test.message)
+		}
+	}
+}
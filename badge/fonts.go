@@ -0,0 +1,46 @@
+package badge
+
+import "unicode"
+
+// Advance width of the printable ascii characters in Verdana, in font units (2048 units per em)
+// Starts at ' ' (0x20) and ends at '~' (0x7E)
+var verdanaWidths = [...]float64{
+	720, 824, 1002, 1836, 1424, 2456, 1636, 553, 1021, 1021, 1424, 1836, 823, 1021, 823, 1021, // ' ' to '/'
+	1424, 1424, 1424, 1424, 1424, 1424, 1424, 1424, 1424, 1424, // '0' to '9'
+	1021, 1021, 1836, 1836, 1836, 1231, 2052, // ':' to '@'
+	1402, 1405, 1432, 1583, 1295, 1178, 1578, 1541, 860, 1023, 1415, 1156, 1720, // 'A' to 'M'
+	1532, 1613, 1272, 1613, 1425, 1402, 1263, 1527, 1402, 2030, 1404, 1259, 1404, // 'N' to 'Z'
+	1021, 1021, 1021, 1836, 1424, 1424, // '[' to '`'
+	1230, 1261, 1064, 1261, 1219, 720, 1261, 1291, 562, 676, 1192, 562, 1983, // 'a' to 'm'
+	1291, 1239, 1261, 1261, 874, 1064, 807, 1291, 1192, 1654, 1192, 1192, 1056, // 'n' to 'z'
+	1270, 1021, 1270, 1836, // '{' to '~'
+}
+
+const verdanaUnitsPerEm = 2048
+
+// Width used for characters that are not inside of verdanaWidths
+const verdanaDefaultWidth = 1432
+
+// Bold text is about 10% wider than regular text in Verdana
+const verdanaBoldRatio = 1.1
+
+// Measure the width in pixels of a text rendered in Verdana
+func textWidth(text string, font_size float64, bold bool) (width float64) {
+	for _, r := range text {
+		units := float64(verdanaDefaultWidth)
+		if r >= ' ' && r <= '~' {
+			units = verdanaWidths[r-' ']
+		} else if unicode.Is(unicode.Mn, r) {
+			// Combining marks do not take any space
+			units = 0
+		} else if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			// CJK characters are rendered as wide as the font size
+			units = verdanaUnitsPerEm
+		}
+		width += units * font_size / verdanaUnitsPerEm
+	}
+	if bold {
+		width *= verdanaBoldRatio
+	}
+	return
+}
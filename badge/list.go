@@ -0,0 +1,71 @@
+package badge
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+// How a list of favorites is added to the file
+const (
+	// One badge per song, stacked on top of each other
+	BadgesList = "badges"
+	// An html table with one row per song, shown by the markdown of every git forge
+	TableList = "table"
+)
+
+// Markdown of a badge, linking to the song if it has a link
+func Markdown(song providers.Song, image_link string) string {
+	if song.Link != "" {
+		return fmt.Sprintf("[<img src=\"%v\" alt=\"Favorite music badge\"/>](%v)", image_link, song.Link)
+	}
+	return fmt.Sprintf("![Favorite music badge](%v)", image_link)
+}
+
+// Stack the markdown of the badges on top of each other, everything stays on a single line
+func Stack(badges []string) string {
+	return strings.Join(badges, "<br>")
+}
+
+// Html table of the songs, everything stays on a single line
+//
+// The plays column is only added if a provider knows about the play counts.
+func Table(songs []providers.Song) string {
+	header := "Song"
+	show_plays := false
+	for _, song := range songs {
+		if song.PlayCount > 0 {
+			show_plays = true
+		}
+		switch song.Entity {
+		case providers.EntityArtist:
+			header = "Artist"
+		case providers.EntityAlbum:
+			header = "Album"
+		}
+	}
+
+	var table strings.Builder
+	table.WriteString("<table><tr><th>#</th><th>" + header + "</th>")
+	if show_plays {
+		table.WriteString("<th>Plays</th>")
+	}
+	table.WriteString("</tr>")
+
+	for i, song := range songs {
+		message := html.EscapeString(Message(song))
+		if song.Link != "" {
+			message = fmt.Sprintf("<a href=\"%v\">%v</a>", html.EscapeString(song.Link), message)
+		}
+		fmt.Fprintf(&table, "<tr><td>%v</td><td>%v</td>", i+1, message)
+		if show_plays {
+			fmt.Fprintf(&table, "<td>%v</td>", song.PlayCount)
+		}
+		table.WriteString("</tr>")
+	}
+
+	table.WriteString("</table>")
+	return table.String()
+}
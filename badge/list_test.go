@@ -0,0 +1,60 @@
+package badge
+
+import (
+	"testing"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+func TestTable(t *testing.T) {
+	tests := []struct {
+		name  string
+		songs []providers.Song
+		want  string
+	}{
+		{
+			// Without play counts there is no plays column
+			name: "tracks",
+			songs: []providers.Song{
+				{Entity: providers.EntityTrack, Title: "One", Artists: []string{"A"}, Link: "https://example.com/?a=1&b=2"},
+				{Entity: providers.EntityTrack, Title: "<Two>", Artists: []string{"B & C"}},
+			},
+			want: `<table><tr><th>#</th><th>Song</th></tr>` +
+				`<tr><td>1</td><td><a href="https://example.com/?a=1&amp;b=2">One by A</a></td></tr>` +
+				`<tr><td>2</td><td>&lt;Two&gt; by B &amp; C</td></tr></table>`,
+		},
+		{
+			// One play count is enough to add the column
+			name: "artists with plays",
+			songs: []providers.Song{
+				{Entity: providers.EntityArtist, Artists: []string{"A"}, PlayCount: 30},
+				{Entity: providers.EntityArtist, Artists: []string{"B"}},
+			},
+			want: `<table><tr><th>#</th><th>Artist</th><th>Plays</th></tr>` +
+				`<tr><td>1</td><td>A</td><td>30</td></tr>` +
+				`<tr><td>2</td><td>B</td><td>0</td></tr></table>`,
+		},
+		{
+			name:  "albums",
+			songs: []providers.Song{{Entity: providers.EntityAlbum, Artists: []string{"A"}, Album: "First", PlayCount: 5}},
+			want:  `<table><tr><th>#</th><th>Album</th><th>Plays</th></tr><tr><td>1</td><td>First by A</td><td>5</td></tr></table>`,
+		},
+	}
+	for _, test := range tests {
+		if table := Table(test.songs); table != test.want {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.name, table, test.want)
+		}
+	}
+}
+
+func TestStack(t *testing.T) {
+	songs := []providers.Song{
+		{Title: "One", Artists: []string{"A"}, Link: "https://example.com/one"},
+		{Title: "Two", Artists: []string{"B"}},
+	}
+	badges := []string{Markdown(songs[0], "one.svg"), Markdown(songs[1], "two.svg")}
+	want := `[<img src="one.svg" alt="Favorite music badge"/>](https://example.com/one)<br>![Favorite music badge](two.svg)`
+	if stack := Stack(badges); stack != want {
+		t.Errorf("Got %v, want %v", stack, want)
+	}
+}
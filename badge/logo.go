@@ -0,0 +1,85 @@
+package badge
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Where the simple-icons logos are downloaded from, can be changed to use a mirror
+var SimpleIconsUrl = "https://cdn.simpleicons.org"
+
+// The logos are small, anything bigger than that is probably not a logo
+const maxLogoSize = 1 << 20
+
+// Convert the logo to a data uri, so that the svg doesn't need another request to be shown
+//
+// The logo can be a data uri (kept as is), an http(s) url, or the name of a simple-icons logo (like shields.io).
+func embedLogo(ctx context.Context, logo string, logo_color string, style string) (data_uri string, err error) {
+	if strings.HasPrefix(logo, "data:") {
+		return logo, nil
+	}
+
+	logo_url := logo
+	if !strings.HasPrefix(logo, "http://") && !strings.HasPrefix(logo, "https://") {
+		color := strings.TrimPrefix(normalizeColor(logo_color, ""), "#")
+		if color == "" {
+			color = "white"
+			if style == Social {
+				color = "333"
+			}
+		}
+		logo_url = fmt.Sprintf("%v/%v/%v", strings.TrimSuffix(SimpleIconsUrl, "/"), url.PathEscape(simpleIconsSlug(logo)), url.PathEscape(color))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, logo_url, nil)
+	if err != nil {
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Request to %v failed with status: %v", logo_url, resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxLogoSize))
+	if err != nil {
+		return
+	}
+
+	content_type := resp.Header.Get("Content-Type")
+	if content_type == "" {
+		content_type = http.DetectContentType(content)
+	}
+	if i := strings.Index(content_type, ";"); i != -1 {
+		content_type = content_type[:i]
+	}
+	// Svg files are often served as text or xml
+	if !strings.HasPrefix(content_type, "image/") && strings.Contains(string(content), "<svg") {
+		content_type = "image/svg+xml"
+	}
+	return fmt.Sprintf("data:%v;base64,%v", content_type, base64.StdEncoding.EncodeToString(content)), nil
+}
+
+// Convert the name of a shields.io logo to the slug of the simple-icons logo
+//
+// shields.io accepts the title of the logo with dashes or spaces (youtube-music or "YouTube Music"),
+// simple-icons only knows youtubemusic. The special characters are replaced like simple-icons does (node.js is nodedotjs).
+func simpleIconsSlug(logo string) string {
+	slug := strings.ToLower(strings.TrimSpace(logo))
+	slug = strings.NewReplacer("+", "plus", ".", "dot", "&", "and").Replace(slug)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, slug)
+}
@@ -0,0 +1,112 @@
+package badge
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+const TEST_LOGO = `<svg xmlns="http://www.w3.org/2000/svg"></svg>`
+
+// Fake simple-icons that only knows youtubemusic, returns the path of every request
+func fakeSimpleIcons(t *testing.T) *[]string {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		if !strings.HasPrefix(r.URL.Path, "/youtubemusic/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+		w.Write([]byte(TEST_LOGO))
+	}))
+	t.Cleanup(server.Close)
+
+	default_url := SimpleIconsUrl
+	SimpleIconsUrl = server.URL
+	t.Cleanup(func() { SimpleIconsUrl = default_url })
+	return &requests
+}
+
+func TestSimpleIconsSlug(t *testing.T) {
+	tests := []struct {
+		logo string
+		slug string
+	}{
+		{"youtubemusic", "youtubemusic"},
+		{"youtube-music", "youtubemusic"},
+		{"YouTube Music", "youtubemusic"},
+		{"last.fm", "lastdotfm"},
+		{"node.js", "nodedotjs"},
+		{"c++", "cplusplus"},
+		{"AT&T", "atandt"},
+		{" spotify ", "spotify"},
+	}
+	for _, test := range tests {
+		if slug := simpleIconsSlug(test.logo); slug != test.slug {
+			t.Errorf("%q: got %q, want %q", test.logo, slug, test.slug)
+		}
+	}
+}
+
+func TestEmbedLogo(t *testing.T) {
+	requests := fakeSimpleIcons(t)
+	data_uri := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(TEST_LOGO))
+
+	tests := []struct {
+		logo       string
+		logo_color string
+		style      string
+		path       string
+	}{
+		{"youtube-music", "", Flat, "/youtubemusic/white"},
+		{"YouTube Music", "red", Flat, "/youtubemusic/e05d44"},
+		{"youtubemusic", "", Social, "/youtubemusic/333"},
+	}
+	for _, test := range tests {
+		*requests = nil
+		logo, err := embedLogo(context.Background(), test.logo, test.logo_color, test.style)
+		if err != nil {
+			t.Errorf("%v: %v", test.logo, err)
+			continue
+		}
+		if logo != data_uri {
+			t.Errorf("%v: got %v, want %v", test.logo, logo, data_uri)
+		}
+		if len(*requests) != 1 || (*requests)[0] != test.path {
+			t.Errorf("%v: requested %v, want %v", test.logo, *requests, test.path)
+		}
+	}
+
+	// Data uris are kept as is
+	if logo, err := embedLogo(context.Background(), data_uri, "", Flat); err != nil || logo != data_uri {
+		t.Errorf("Got %v and %v for a data uri", logo, err)
+	}
+	if _, err := embedLogo(context.Background(), "missing", "", Flat); err == nil {
+		t.Error("No error for a logo that doesn't exist")
+	}
+}
+
+func TestGenerateSvgLogo(t *testing.T) {
+	fakeSimpleIcons(t)
+	song := providers.Song{Title: "Song", Artists: []string{"Artist"}}
+
+	svg, err := Generate_svg(context.Background(), song, Options{Logo: "youtube-music"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(svg), `<image x="5" y="3" width="14" height="14" xlink:href="data:image/svg+xml;base64,`) {
+		t.Errorf("The logo is missing: %s", svg)
+	}
+
+	// A logo that can't be downloaded isn't silently dropped
+	_, err = Generate_svg(context.Background(), song, Options{Logo: "missing"})
+	if err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("Got %v, want an error about the logo", err)
+	}
+}
@@ -0,0 +1,224 @@
+package badge
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+// Styles supported by the svg renderer, the same as shields.io
+const (
+	Flat        = "flat"
+	FlatSquare  = "flat-square"
+	Plastic     = "plastic"
+	ForTheBadge = "for-the-badge"
+	Social      = "social"
+)
+
+// Size of the logo, and the space between the logo and the label
+const logoSize = 14
+const logoPadding = 3
+
+// How a style of badge is drawn (everything is in pixels)
+type svgStyle struct {
+	height     float64
+	fontSize   float64
+	padding    float64
+	rx         float64
+	uppercase  bool
+	boldLabel  bool
+	boldText   bool
+	letterSize float64
+	// Text position in tenths of pixels, shadowY is 0 when there is no text shadow
+	textY   int
+	shadowY int
+	logoY   float64
+	// Definition of the gradient with id "s", empty if there is none
+	gradient string
+}
+
+var svgStyles = map[string]svgStyle{
+	Flat: {
+		height: 20, fontSize: 11, padding: 5, rx: 3,
+		textY: 140, shadowY: 150, logoY: 3,
+		gradient: `<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`,
+	},
+	FlatSquare: {
+		height: 20, fontSize: 11, padding: 5,
+		textY: 140, logoY: 3,
+	},
+	Plastic: {
+		height: 18, fontSize: 11, padding: 5, rx: 4,
+		textY: 130, shadowY: 140, logoY: 2,
+		gradient: `<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#fff" stop-opacity=".7"/><stop offset=".1" stop-color="#aaa" stop-opacity=".1"/><stop offset=".9" stop-color="#000" stop-opacity=".3"/><stop offset="1" stop-color="#000" stop-opacity=".5"/></linearGradient>`,
+	},
+	ForTheBadge: {
+		height: 28, fontSize: 10, padding: 10, uppercase: true, boldText: true, letterSize: 1.25,
+		textY: 175, logoY: 7,
+	},
+}
+
+// Generate an svg badge of the song that looks the same as the shields.io one,
+// so that the badge can be committed as a file instead of depending on shields.io
+//
+// The logo is downloaded and embedded inside of the svg, an error is returned if it can't be downloaded.
+func Generate_svg(ctx context.Context, song providers.Song, options Options) (svg []byte, err error) {
+	logo := ""
+	if options.Logo != "" {
+		logo, err = embedLogo(ctx, options.Logo, options.LogoColor, options.Style)
+		if err != nil {
+			return nil, fmt.Errorf("While getting the logo \"%v\" (use -logo \"\" for a badge without logo): %w", options.Logo, err)
+		}
+	}
+
+	color := normalizeColor(options.MessageColor, "#ffe4e1")
+	color = normalizeColor(options.Color, color)
+	label_color := normalizeColor(options.LabelColor, "#555")
+
+	if options.Style == Social {
+		return []byte(renderSocial(Label(song), Message(song), logo)), nil
+	}
+
+	style, ok := svgStyles[options.Style]
+	if !ok {
+		style = svgStyles[Flat]
+	}
+	return []byte(renderSvg(style, Label(song), Message(song), logo, label_color, color)), nil
+}
+
+// Width of the text once rendered with that style, rounded down then up to an odd number of
+// pixels like shields.io does to align the texts on the pixels
+func (style svgStyle) measure(text string, bold bool) float64 {
+	if text == "" {
+		return 0
+	}
+	width := math.Floor(textWidth(text, style.fontSize, bold) + style.letterSize*float64(utf8.RuneCountInString(text)))
+	if math.Mod(width, 2) == 0 {
+		width++
+	}
+	return width
+}
+
+// Colors of the text and of its shadow on top of that background
+func textColors(background string) (text string, shadow string) {
+	if isLightColor(background) {
+		return "#333", "#ccc"
+	}
+	return "#fff", "#010101"
+}
+
+// Write a text, with its shadow if the style has one
+func writeText(svg *strings.Builder, style svgStyle, text string, x float64, width float64, background string, bold bool) {
+	color, shadow := textColors(background)
+	weight := ""
+	if bold {
+		weight = ` font-weight="bold"`
+	}
+	text = html.EscapeString(text)
+	if style.shadowY != 0 {
+		fmt.Fprintf(svg, `<text aria-hidden="true" x="%v" y="%v" fill="%v" fill-opacity=".3" transform="scale(.1)" textLength="%v"%v>%v</text>`, tenths(x), style.shadowY, shadow, tenths(width), weight, text)
+	}
+	fmt.Fprintf(svg, `<text x="%v" y="%v" transform="scale(.1)" fill="%v" textLength="%v"%v>%v</text>`, tenths(x), style.textY, color, tenths(width), weight, text)
+}
+
+// Convert pixels to the tenths of pixels used by the scaled text
+func tenths(pixels float64) int {
+	return int(math.Round(pixels * 10))
+}
+
+// Render the flat, flat-square, plastic and for-the-badge styles
+func renderSvg(style svgStyle, label string, message string, logo string, label_color string, color string) string {
+	title := html.EscapeString(label + ": " + message)
+	if style.uppercase {
+		label = strings.ToUpper(label)
+		message = strings.ToUpper(message)
+	}
+
+	logo_width := 0.0
+	if logo != "" {
+		logo_width = logoSize + logoPadding
+	}
+	label_width := style.measure(label, style.boldLabel)
+	message_width := style.measure(message, style.boldText)
+	left := label_width + 2*style.padding + logo_width
+	right := message_width + 2*style.padding
+	total := left + right
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%v" height="%v" role="img" aria-label="%v">`, total, style.height, title)
+	fmt.Fprintf(&svg, `<title>%v</title>`, title)
+	svg.WriteString(style.gradient)
+	fmt.Fprintf(&svg, `<clipPath id="r"><rect width="%v" height="%v" rx="%v" fill="#fff"/></clipPath>`, total, style.height, style.rx)
+	fmt.Fprintf(&svg, `<g clip-path="url(#r)"><rect width="%v" height="%v" fill="%v"/><rect x="%v" width="%v" height="%v" fill="%v"/>`, left, style.height, label_color, left, right, style.height, color)
+	if style.gradient != "" {
+		fmt.Fprintf(&svg, `<rect width="%v" height="%v" fill="url(#s)"/>`, total, style.height)
+	}
+	svg.WriteString(`</g>`)
+	fmt.Fprintf(&svg, `<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="%v">`, tenths(style.fontSize))
+	if logo != "" {
+		fmt.Fprintf(&svg, `<image x="%v" y="%v" width="%v" height="%v" xlink:href="%v"/>`, style.padding, style.logoY, logoSize, logoSize, html.EscapeString(logo))
+	}
+	// Like on shields.io, the label is 1 pixel to the right and the message 1 pixel to the left
+	writeText(&svg, style, label, 1+style.padding+logo_width+label_width/2, label_width, label_color, style.boldLabel)
+	writeText(&svg, style, message, left-1+style.padding+message_width/2, message_width, color, style.boldText)
+	svg.WriteString(`</g></svg>`)
+	return svg.String()
+}
+
+// Render the social style, the colors are ignored like on shields.io
+func renderSocial(label string, message string, logo string) string {
+	title := html.EscapeString(label + ": " + message)
+	if first, size := utf8.DecodeRuneInString(label); size > 0 {
+		label = string(unicode.ToUpper(first)) + label[size:]
+	}
+
+	style := svgStyle{height: 20, fontSize: 11, padding: 5, textY: 140, shadowY: 150, logoY: 3}
+	logo_width := 0.0
+	if logo != "" {
+		logo_width = logoSize + logoPadding
+	}
+	label_width := style.measure(label, false)
+	message_width := style.measure(message, false)
+	left := label_width + 2*style.padding + logo_width
+	right := message_width + 2*style.padding
+	// The message is inside of a bubble, 6 pixels after the label
+	bubble := left + 6
+	total := bubble + right + 1
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%v" height="20" role="img" aria-label="%v">`, total, title)
+	fmt.Fprintf(&svg, `<title>%v</title>`, title)
+	svg.WriteString(`<style>a:hover #llink{fill:url(#b);stroke:#ccc}a:hover #rlink{fill:#4183c4}</style>`)
+	svg.WriteString(`<linearGradient id="a" x2="0" y2="100%"><stop offset="0" stop-color="#fcfcfc" stop-opacity="0"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	svg.WriteString(`<linearGradient id="b" x2="0" y2="100%"><stop offset="0" stop-color="#ccc" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&svg, `<g stroke="#d5d5d5"><rect stroke="none" fill="#fcfcfc" x="0.5" y="0.5" width="%v" height="19" rx="2"/>`, left-1)
+	fmt.Fprintf(&svg, `<rect x="%v" y="0.5" width="%v" height="19" rx="2" fill="#fafafa"/>`, bubble+0.5, right)
+	fmt.Fprintf(&svg, `<rect x="%v" y="7.5" width="0.5" height="5" stroke="#fafafa"/>`, bubble)
+	fmt.Fprintf(&svg, `<path d="M%v 6.5 l-3 3v1 l3 3" stroke="#d5d5d5" fill="#fafafa"/></g>`, bubble+0.5)
+	if logo != "" {
+		fmt.Fprintf(&svg, `<image x="%v" y="%v" width="%v" height="%v" xlink:href="%v"/>`, style.padding, style.logoY, logoSize, logoSize, html.EscapeString(logo))
+	}
+	svg.WriteString(`<g aria-hidden="true" fill="#333" text-anchor="middle" font-family="Helvetica Neue,Helvetica,Arial,sans-serif" text-rendering="geometricPrecision" font-weight="700" font-size="110px" line-height="14px">`)
+	fmt.Fprintf(&svg, `<rect id="llink" stroke="#d5d5d5" fill="url(#a)" x=".5" y=".5" width="%v" height="19" rx="2"/>`, left-1)
+	for _, text := range []struct {
+		value string
+		x     float64
+		width float64
+		id    string
+	}{
+		{label, style.padding + logo_width + label_width/2, label_width, ""},
+		{message, bubble + right/2, message_width, ` id="rlink"`},
+	} {
+		escaped := html.EscapeString(text.value)
+		fmt.Fprintf(&svg, `<text aria-hidden="true" x="%v" y="%v" fill="#fff" transform="scale(.1)" textLength="%v">%v</text>`, tenths(text.x), style.shadowY, tenths(text.width), escaped)
+		fmt.Fprintf(&svg, `<text%v x="%v" y="%v" transform="scale(.1)" textLength="%v">%v</text>`, text.id, tenths(text.x), style.textY, tenths(text.width), escaped)
+	}
+	svg.WriteString(`</g></svg>`)
+	return svg.String()
+}
@@ -0,0 +1,158 @@
+package badge
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var svgWidth = regexp.MustCompile(`^<svg [^>]*width="([0-9.]+)"`)
+
+func TestNormalizeColor(t *testing.T) {
+	tests := []struct {
+		color string
+		want  string
+	}{
+		{"brightgreen", "#4c1"},
+		{"Blue", "#007ec6"},
+		{"MistyRose", "mistyrose"},
+		{"ff0000", "#ff0000"},
+		{"#ABC", "#abc"},
+		{"12345678", "#12345678"},
+		{"rgb(1, 2, 3)", "rgb(1, 2, 3)"},
+		{"HSLA(1, 2%, 3%, .5)", "hsla(1, 2%, 3%, .5)"},
+		{"", "fallback"},
+		{"12345", "fallback"},
+		{"notacolor", "fallback"},
+		{"rgb(1,2,3);fill:red", "fallback"},
+	}
+	for _, test := range tests {
+		if color := normalizeColor(test.color, "fallback"); color != test.want {
+			t.Errorf("%q: got %q, want %q", test.color, color, test.want)
+		}
+	}
+}
+
+func TestIsLightColor(t *testing.T) {
+	tests := []struct {
+		color string
+		light bool
+	}{
+		{"#ffe4e1", true},
+		{"mistyrose", true},
+		{"#fff", true},
+		{"#ffff", true},
+		{"#dfb317", false},
+		{"#555", false},
+		{"#000000", false},
+		{"black", false},
+		// Only named and hex colors are known
+		{"rgb(255, 255, 255)", false},
+		{"#12", false},
+	}
+	for _, test := range tests {
+		if light := isLightColor(test.color); light != test.light {
+			t.Errorf("%q: got %v, want %v", test.color, light, test.light)
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		text      string
+		font_size float64
+		bold      bool
+		width     float64
+	}{
+		{"", 11, false, 0},
+		// b, u, i, l and d
+		{"build", 11, false, (1261 + 1291 + 562 + 562 + 1261) * 11.0 / 2048},
+		{"build", 11, true, (1261 + 1291 + 562 + 562 + 1261) * 11.0 / 2048 * 1.1},
+		{"build", 22, false, (1261 + 1291 + 562 + 562 + 1261) * 22.0 / 2048},
+		// CJK characters are as wide as the font size
+		{"音楽", 11, false, 22},
+		// The combining accent doesn't take any space
+		{"é", 11, false, 1219 * 11.0 / 2048},
+		// The precomposed accent isn't inside of the table
+		{"é", 11, false, verdanaDefaultWidth * 11.0 / 2048},
+	}
+	for _, test := range tests {
+		if width := textWidth(test.text, test.font_size, test.bold); math.Abs(width-test.width) > 1e-9 {
+			t.Errorf("%q: got %v, want %v", test.text, width, test.width)
+		}
+	}
+}
+
+// The widths of flat, flat-square and plastic come from the badges of img.shields.io
+func TestRenderSvgWidth(t *testing.T) {
+	logo := "data:image/svg+xml;base64,"
+	tests := []struct {
+		style   string
+		label   string
+		message string
+		logo    string
+		width   string
+	}{
+		{Flat, "build", "passing", "", "88"},
+		{Flat, "license", "MIT", "", "78"},
+		// 14 pixels of logo and 3 of padding
+		{Flat, "build", "passing", logo, "105"},
+		{FlatSquare, "build", "passing", "", "88"},
+		{Plastic, "license", "MIT", logo, "95"},
+		{ForTheBadge, "build", "passing", "", "138"},
+		{ForTheBadge, "license", "MIT", "", "118"},
+	}
+	for _, test := range tests {
+		svg := renderSvg(svgStyles[test.style], test.label, test.message, test.logo, "#555", "#ffe4e1")
+
+		match := svgWidth.FindStringSubmatch(svg)
+		if match == nil {
+			t.Fatalf("%v: no width: %v", test.style, svg)
+		}
+		if match[1] != test.width {
+			t.Errorf("%v %v|%v with logo %v: got the width %v, want %v", test.style, test.label, test.message, test.logo != "", match[1], test.width)
+		}
+		if strings.Contains(svg, "<image") != (test.logo != "") {
+			t.Errorf("%v: the logo is wrong: %v", test.style, svg)
+		}
+		// Dark text on top of the light message color
+		if !strings.Contains(svg, `fill="#333"`) {
+			t.Errorf("%v: the message isn't dark: %v", test.style, svg)
+		}
+	}
+
+	// Same positions as the texts of img.shields.io
+	svg := renderSvg(svgStyles[Flat], "build", "passing", "", "#555", "#4c1")
+	for _, text := range []string{`x="195" y="140" transform="scale(.1)" fill="#fff" textLength="270">build<`, `x="615" y="140" transform="scale(.1)" fill="#fff" textLength="410">passing<`} {
+		if !strings.Contains(svg, text) {
+			t.Errorf("No %v: %v", text, svg)
+		}
+	}
+}
+
+func TestRenderSocialWidth(t *testing.T) {
+	tests := []struct {
+		label   string
+		message string
+		width   string
+	}{
+		{"build", "passing", "95"},
+		{"license", "MIT", "89"},
+	}
+	for _, test := range tests {
+		svg := renderSocial(test.label, test.message, "")
+		match := svgWidth.FindStringSubmatch(svg)
+		if match == nil {
+			t.Fatalf("No width: %v", svg)
+		}
+		if match[1] != test.width {
+			t.Errorf("%v|%v: got the width %v, want %v", test.label, test.message, match[1], test.width)
+		}
+	}
+
+	svg := renderSocial("favorite music", "Song by Artist", "")
+	if !strings.Contains(svg, ">Favorite music</text>") {
+		t.Errorf("The label isn't capitalized: %v", svg)
+	}
+}
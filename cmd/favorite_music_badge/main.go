@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/badge"
+	"codeberg.org/virtualfuzz/favorite_music_badge/config"
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+	"codeberg.org/virtualfuzz/favorite_music_badge/repository"
+	"github.com/joho/godotenv"
+)
+
+// Exit code of -dryRun when the file would be changed
+const DRY_RUN_CHANGED_EXIT_CODE = 2
+
+func main() {
+	godotenv.Load()
+
+	options, err := config.Parse(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		options.Usage()
+		os.Exit(0)
+	} else if err != nil {
+		log.Printf("[ERROR] %v", err)
+		options.Usage()
+		os.Exit(64)
+	}
+
+	// Without blocks, the options fill the markers without an id
+	blocks := options.Blocks
+	if len(blocks) == 0 {
+		blocks = []config.Block{{Options: options}}
+	}
+
+	contents := map[string]string{}
+	badge_files := map[string][]byte{}
+	for i, block := range blocks {
+		if block.Id != "" {
+			fmt.Printf("Filling the block %v\n", block.Id)
+		}
+
+		// Fetch the favorite music
+		songs, err := providers.GetFavorites(block.Providers, block.Count, block.Timeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Create the badges or the table of it
+		content, files, err := generateContent(songs, block.Options)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, song := range songs {
+			if song.Link != "" {
+				fmt.Printf("%v: %v ( %v )\n", badge.Label(song), badge.Message(song), song.Link)
+			} else {
+				fmt.Printf("%v: %v ( no song link found )\n", badge.Label(song), badge.Message(song))
+			}
+		}
+		fmt.Println(content)
+
+		contents[block.Id] = content
+		maps.Copy(badge_files, files)
+		// The placeholders of the commit message are the first song of the first block
+		if i == 0 {
+			options.Git.FillCommitMessage(songs[0])
+		}
+	}
+
+	if options.LocalPath != "" {
+		err = repository.UpdateLocalRepository(options.LocalPath, options.Filename, contents, badge_files, options.Git)
+	} else if options.Repository != "" {
+		// Stop cloning or pushing on ctrl+c, so that the clone is still removed
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		if options.Git.ForgeApi {
+			fmt.Println("The image link has been generated we are now updating the file with the API of the forge!")
+			err = repository.UpdateWithForgeApi(ctx, options.Repository, options.Filename, contents, badge_files, options.Git)
+		} else {
+			fmt.Println("The image link has been generated we are now downloading the repository and adding the favorite_music_badge to it!")
+			err = repository.AddImageToRepository(ctx, options.Repository, options.Filename, contents, badge_files, options.Git)
+		}
+		stop()
+	}
+	if errors.Is(err, repository.ErrWouldChange) {
+		log.Print(err)
+		os.Exit(DRY_RUN_CHANGED_EXIT_CODE)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Generate the markdown added inside of the file and the files that need to be added to the repository with it
+//
+// A list of songs is either a table or a stack of badges, depending on options.ListStyle.
+func generateContent(songs []providers.Song, options config.Options) (content string, badge_files map[string][]byte, err error) {
+	if len(songs) > 1 && options.ListStyle == badge.TableList {
+		return badge.Table(songs), nil, nil
+	}
+
+	badge_files = map[string][]byte{}
+	var badges []string
+	for i, song := range songs {
+		var image_link string
+		var files map[string][]byte
+		image_link, files, err = generateBadge(song, svgFilename(options.SvgFilename, i), options)
+		if err != nil {
+			return
+		}
+		maps.Copy(badge_files, files)
+		badges = append(badges, badge.Markdown(song, image_link))
+	}
+	return badge.Stack(badges), badge_files, nil
+}
+
+// Name of the svg of the song at that index inside of the list, the first one keeps the name
+// (favorite_music_badge.svg, favorite_music_badge_2.svg, ...)
+func svgFilename(filename string, index int) string {
+	if index == 0 {
+		return filename
+	}
+	extension := filepath.Ext(filename)
+	return fmt.Sprintf("%v_%v%v", strings.TrimSuffix(filename, extension), index+1, extension)
+}
+
+// Generate the badge, returns the link to add inside of the file and the files that need to be
+// added to the repository with it
+//
+// Without a file to update, the svg badge is directly written to svg_filename.
+func generateBadge(song providers.Song, svg_filename string, options config.Options) (image_link string, badge_files map[string][]byte, err error) {
+	if options.Renderer != badge.SvgRenderer {
+		return badge.Generate_image_link(song, options.Badge), nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+	defer cancel()
+	svg, err := badge.Generate_svg(ctx, song, options.Badge)
+	if err != nil {
+		return
+	}
+
+	if options.Filename == "" {
+		err = os.WriteFile(svg_filename, svg, 0644)
+		return svg_filename, nil, err
+	}
+
+	// Reference the svg relatively to the file so that it works on every git forge
+	image_link, err = filepath.Rel(filepath.Dir(options.Filename), svg_filename)
+	if err != nil {
+		return
+	}
+	return filepath.ToSlash(image_link), map[string][]byte{svg_filename: svg}, nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestSvgFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		index    int
+		want     string
+	}{
+		{"docs/favorite_music_badge.svg", 0, "docs/favorite_music_badge.svg"},
+		{"docs/favorite_music_badge.svg", 1, "docs/favorite_music_badge_2.svg"},
+		{"docs/favorite_music_badge.svg", 9, "docs/favorite_music_badge_10.svg"},
+		{"badge", 2, "badge_3"},
+		{"my.badges/top.svg", 1, "my.badges/top_2.svg"},
+	}
+	for _, test := range tests {
+		if filename := svgFilename(test.filename, test.index); filename != test.want {
+			t.Errorf("%v at %v: got %v, want %v", test.filename, test.index, filename, test.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/badge"
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+// Key of the config file that holds the list of blocks
+const BLOCKS_KEY = "blocks"
+
+// Options that are shared by every block, they can't be changed inside of one
+var globalOnlyFlags = []string{
+	"config", "repository", "filename", "keepClone", "localPath", "localCommit", "dryRun",
+	"pullRequestBranch", "forgeApi", "forge", "forgeUrl", "forgeToken", "forgeProject",
+	"branch", "commitMessage", "commitTrailers", "authorName", "authorEmail", "committerName", "committerEmail",
+	"signingKeyFile", "signingKey", "signingKeyPassphrase", "pushRetries",
+}
+
+// Provider that registers each flag, to know which global options a block inherits
+func flagProviders() map[string]providers.ProviderType {
+	owners := map[string]providers.ProviderType{}
+	for _, provider := range providers.New() {
+		flags := flag.NewFlagSet(string(provider.Name()), flag.ContinueOnError)
+		provider.RegisterFlags(flags)
+		flags.VisitAll(func(f *flag.Flag) {
+			owners[f.Name] = provider.Name()
+		})
+	}
+	return owners
+}
+
+// Providers used by a block: the ones it sets an option of or lists inside of its fallback
+func blockProviders(values map[string]string, owners map[string]providers.ProviderType) map[providers.ProviderType]bool {
+	used := map[providers.ProviderType]bool{}
+	for key := range values {
+		if owner, ok := owners[key]; ok {
+			used[owner] = true
+		}
+	}
+	if fallback, ok := values["fallback"]; ok {
+		for _, name := range strings.Split(fallback, ",") {
+			used[providers.ProviderType(strings.ToLower(strings.TrimSpace(name)))] = true
+		}
+	}
+	return used
+}
+
+// A marker block of the file (FAVORITE_MUSIC_BADGE:START id=weekly) filled with its own options
+type Block struct {
+	// Id of the markers, empty for the markers without an id
+	Id string
+	Options
+}
+
+// Create the options of every block of the config file
+//
+// A block starts from the global options (flags, environment variables and the rest of the config
+// file), then the keys of the block are applied on top of them. The global options of the providers
+// (and the fallback) are only inherited by the providers that the block uses, a block that doesn't
+// set any provider option inherits all of them.
+func parseBlocks(global *flag.FlagSet, raw_blocks []map[string]string) (blocks []Block, err error) {
+	config_file := global.Lookup("config").Value.String()
+	owners := flagProviders()
+	seen := map[string]bool{}
+	for _, values := range raw_blocks {
+		block := Block{Id: values["id"]}
+		if seen[block.Id] {
+			return nil, fmt.Errorf("The block \"%v\" is defined twice inside of the config file %v", block.Id, config_file)
+		}
+		if strings.ContainsAny(block.Id, " \t\"'<>") {
+			return nil, fmt.Errorf("Invalid block id \"%v\" inside of the config file %v, it can't contain spaces, quotes, < or >", block.Id, config_file)
+		}
+		seen[block.Id] = true
+
+		flags, available := block.Options.newFlagSet()
+		used := blockProviders(values, owners)
+		global.VisitAll(func(f *flag.Flag) {
+			owner, is_provider := owners[f.Name]
+			if len(used) > 0 && (f.Name == "fallback" || is_provider && !used[owner]) {
+				return
+			}
+			if err == nil && flags.Lookup(f.Name) != nil {
+				err = flags.Set(f.Name, f.Value.String())
+			}
+		})
+		if err != nil {
+			return
+		}
+
+		err = checkDuplicateOptions(values, fmt.Sprintf("inside of the block \"%v\" of the config file %v", block.Id, config_file))
+		if err != nil {
+			return
+		}
+		for key, value := range values {
+			if key == "id" {
+				continue
+			}
+			if slices.Contains(globalOnlyFlags, key) {
+				return nil, fmt.Errorf("\"%v\" can't be changed inside of the block \"%v\", it is shared by every block", key, block.Id)
+			}
+			if flags.Lookup(key) == nil {
+				return nil, fmt.Errorf("Unknown option \"%v\" inside of the block \"%v\" of the config file %v", key, block.Id, config_file)
+			}
+			if err = flags.Set(key, value); err != nil {
+				return nil, fmt.Errorf("Invalid value for \"%v\" inside of the block \"%v\" of the config file %v: %w", key, block.Id, config_file, err)
+			}
+		}
+
+		// Every block needs its own svg, otherwise they would overwrite each other
+		if block.SvgFilename == "" && block.Id != "" {
+			svg_filename := fmt.Sprintf("%v_%v.svg", strings.TrimSuffix(badge.SVG_FILENAME, ".svg"), block.Id)
+			block.SvgFilename = path.Join(path.Dir(filepath.ToSlash(block.Filename)), svg_filename)
+		}
+
+		err = block.Options.finish(available)
+		if err != nil {
+			return nil, fmt.Errorf("Inside of the block \"%v\": %w", block.Id, err)
+		}
+		blocks = append(blocks, block)
+	}
+	return
+}
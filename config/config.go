@@ -0,0 +1,339 @@
+// Read the configuration of favorite_music_badge
+//
+// Every option can be given from (the first one wins):
+//  1. a command line flag (-lastFmUsername chimpanzeebee)
+//  2. an environment variable, the flag name in uppercase snake case prefixed with
+//     FAVORITE_MUSIC_BADGE_ (FAVORITE_MUSIC_BADGE_LAST_FM_USERNAME=chimpanzeebee)
+//  3. a YAML config file given by -config, where the keys are the flag names
+//     (lastFmUsername: chimpanzeebee)
+//  4. the default value of the flag
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/badge"
+	"codeberg.org/virtualfuzz/favorite_music_badge/forge"
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+	"codeberg.org/virtualfuzz/favorite_music_badge/repository"
+)
+
+const VERSION = "v1.1.3"
+
+// Most favorites that can be shown, the spotify API doesn't return more than that
+const MAX_COUNT = 50
+
+// Everything needed to generate and publish a favorite music badge
+type Options struct {
+	// Configured providers, in the order given by --fallback
+	Providers []providers.Provider
+	// Timeout before we stop trying to fetch from a provider
+	Timeout time.Duration
+	Badge   badge.Options
+	// How the badge is rendered, badge.ShieldsRenderer or badge.SvgRenderer
+	Renderer string
+	// Where the svg badge is written, relative to the root of the repository if there is one
+	SvgFilename string
+	// Repository to clone and update, empty means we only print the badge
+	Repository string
+	// Working tree already on the disk to update instead of cloning a repository
+	LocalPath string
+	// File inside of the repository where the badge is added
+	Filename string
+	// How the repository is updated
+	Git repository.Options
+	// Config file the options were read from, empty if there is none
+	ConfigFile string
+	// How many favorites are shown, more than 1 shows a top list
+	Count int
+	// How a top list is shown, badge.BadgesList or badge.TableList
+	ListStyle string
+	// Named blocks of the config file, each one with its own options. When there are blocks,
+	// the providers of these options are not used.
+	Blocks []Block
+
+	entity   string
+	fallback string
+	flags    *flag.FlagSet
+}
+
+// Print how to use favorite_music_badge and every flag
+func (options Options) Usage() {
+	if options.flags == nil {
+		return
+	}
+	options.flags.SetOutput(os.Stderr)
+	options.flags.Usage()
+}
+
+// Parse the command line arguments (without the program name), the environment variables and the config file
+//
+// If -h or -help is given, flag.ErrHelp is returned. The returned options can always be used to print the usage.
+//
+// Required:
+// - if filename THEN repository and vice versa
+// - one provider needs to be configured (see providers.Register), inside of every block if there are blocks
+func Parse(args []string) (options Options, err error) {
+	flags, available := options.newFlagSet()
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Version: %s\n", VERSION)
+		fmt.Fprintf(flags.Output(), "This creates a badge that shows your favorite music in youtube music or lastfm.\n")
+		fmt.Fprintf(flags.Output(), "Every flag can also be set with a FAVORITE_MUSIC_BADGE_ environment variable (-lastFmUsername is FAVORITE_MUSIC_BADGE_LAST_FM_USERNAME) or inside of the -config file.\n")
+		flags.PrintDefaults()
+	}
+
+	help := flags.Bool("help", false, "Display help information")
+	helpShort := flags.Bool("h", false, "Display help information")
+	err = flags.Parse(args)
+	if err != nil {
+		return
+	}
+
+	if *help || *helpShort {
+		err = flag.ErrHelp
+		return
+	}
+
+	raw_blocks, err := applyEnvironmentAndConfigFile(flags)
+	if err != nil {
+		return
+	}
+
+	if len(flags.Args()) > 1 {
+		err = fmt.Errorf("Too many arguments given (%v), every option should be passed as a flag.", flags.Args())
+		return
+	} else if len(flags.Args()) == 1 {
+		err = setArgument(available, flags.Args()[0])
+		if err != nil {
+			return
+		}
+	}
+
+	// Every block has its own providers, the global options only give the values shared by the blocks
+	if len(raw_blocks) > 0 {
+		options.Blocks, err = parseBlocks(flags, raw_blocks)
+		if err != nil {
+			return
+		}
+		err = options.Validate()
+		return
+	}
+
+	err = options.finish(available)
+	return
+}
+
+// Create the flags that fill the options, every provider adds its own flags
+func (options *Options) newFlagSet() (flags *flag.FlagSet, available []providers.Provider) {
+	flags = flag.NewFlagSet("favorite_music_badge", flag.ContinueOnError)
+	options.flags = flags
+	// The caller decides if and where the usage is printed
+	flags.SetOutput(io.Discard)
+
+	// Set up the possible flags and arguments that can be passed
+	flags.StringVar(&options.ConfigFile, "config", "", "YAML config file, the keys are the flag names. Flags and environment variables have a higher priority than the config file.")
+	flags.DurationVar(&options.Timeout, "timeout", 60*time.Second, "Timeout before we stop trying to fetch the favorite music.")
+	flags.StringVar(&options.Badge.MessageColor, "message-color", "mistyrose", "[DEPRECATED, use messageColor]")
+	flags.StringVar(&options.Badge.MessageColor, "messageColor", "mistyrose", "messageColor passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges)")
+	flags.StringVar(&options.Badge.Style, "style", "for-the-badge", "style passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges)")
+	flags.StringVar(&options.Badge.Logo, "logo", "youtube-music", "This is not a filename. logo passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges)")
+	flags.StringVar(&options.Badge.LogoColor, "logo-color", "", "[DEPRECATED, use logoColor]")
+	flags.StringVar(&options.Badge.LogoColor, "logoColor", "", "logoColor passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges). Empty means we don't pass it.")
+	flags.StringVar(&options.Badge.LogoSize, "logo-size", "", "[DEPRECATED, use logoSize]")
+	flags.StringVar(&options.Badge.LogoSize, "logoSize", "", "logoSize passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges)")
+	flags.StringVar(&options.Badge.LabelColor, "label-color", "darkred", "[DEPRECATED, use labelColor]")
+	flags.StringVar(&options.Badge.LabelColor, "labelColor", "darkred", "labelColor passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges)")
+	flags.StringVar(&options.Badge.Color, "color", "", "color passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges)")
+	flags.StringVar(&options.Badge.CacheSeconds, "cacheSeconds", "", "cacheSeconds passed to shields.io while generating the markdown badge (documentation at https://shields.io/badges)")
+	flags.StringVar(&options.Renderer, "renderer", badge.ShieldsRenderer, "How the badge is rendered. \"shields\" links to img.shields.io, \"svg\" generates the badge locally into an svg file (see -svgFilename) that is committed next to -filename.")
+	flags.StringVar(&options.SvgFilename, "svgFilename", "", "Where the svg badge is written when -renderer is \"svg\", relative to the repository. Defaults to favorite_music_badge.svg next to -filename.")
+	flags.StringVar(&options.Repository, "repository", "", "repository to clone and update with the new favorite music badge. -file must also be added")
+	flags.StringVar(&options.LocalPath, "localPath", "", "Working tree already on the disk to update instead of cloning -repository, nothing is pushed. -file must also be added")
+	flags.BoolVar(&options.Git.Commit, "localCommit", false, "Commit the change inside of -localPath, by default the files are only modified.")
+	flags.BoolVar(&options.Git.DryRun, "dryRun", false, "Only print the diff of -filename, nothing is written, committed or pushed. Exits with the code 2 if the file would be changed.")
+	flags.StringVar(&options.Filename, "filename", "", "file where we add the new favorite music badge. -repository or -localPath must also be added.")
+	flags.BoolVar(&options.Git.KeepClone, "keepClone", false, "Keep the clone of the repository after the run (its path is printed) instead of removing it, for debugging.")
+	flags.StringVar(&options.Git.Branch, "branch", "", "Branch of -repository to update (or to open the pull request to), the default branch by default.")
+	flags.StringVar(&options.Git.CommitMessage, "commitMessage", repository.COMMIT_MESSAGE, fmt.Sprintf("Message of the commit, %v, %v, %v and %v are replaced by the favorite song.", repository.SONG_PLACEHOLDER, repository.ARTIST_PLACEHOLDER, repository.ALBUM_PLACEHOLDER, repository.PROVIDER_PLACEHOLDER))
+	flags.StringVar(&options.Git.Trailers, "commitTrailers", "", "Lines added at the end of the commit message (\"[skip ci]\" for example), one per line (a list inside of the config file).")
+	flags.StringVar(&options.Git.AuthorName, "authorName", "", "Name of the author of the commit, user.name of the git config by default. -authorEmail must also be added.")
+	flags.StringVar(&options.Git.AuthorEmail, "authorEmail", "", "Email of the author of the commit, user.email of the git config by default.")
+	flags.StringVar(&options.Git.CommitterName, "committerName", "", "Name of the committer of the commit, the author by default. -committerEmail must also be added.")
+	flags.StringVar(&options.Git.CommitterEmail, "committerEmail", "", "Email of the committer of the commit, the author by default.")
+	flags.StringVar(&options.Git.SigningKeyFile, "signingKeyFile", "", "OpenPGP or SSH private key that signs the commits.")
+	flags.StringVar(&options.Git.SigningKey, "signingKey", "", "Content of the OpenPGP or SSH private key that signs the commits, instead of -signingKeyFile (usually given with the environment variable).")
+	flags.StringVar(&options.Git.SigningKeyPassphrase, "signingKeyPassphrase", "", "Passphrase of the signing key, if it is encrypted.")
+	flags.IntVar(&options.Git.PushRetries, "pushRetries", 3, "How many times the badge is added again on top of the new commits when the push is rejected because someone else pushed to the branch, with a delay that doubles every time.")
+	flags.StringVar(&options.Git.PullRequestBranch, "pullRequestBranch", "", "Push to this branch of -repository and open a pull request to the default branch instead of pushing to it. The branch is overwritten on every run and the open pull request is reused. -forge and -forgeToken must also be added.")
+	flags.BoolVar(&options.Git.ForgeApi, "forgeApi", false, "Update -filename of -repository with the API of the forge instead of cloning it, only -forge and -forgeToken are needed (no SSH key).")
+	flags.StringVar(&options.Git.Forge.Type, "forge", "", fmt.Sprintf("Forge hosting -repository, used to open the pull request (%v).", forge.Types))
+	flags.StringVar(&options.Git.Forge.Url, "forgeUrl", "", "Base url of the API of the forge (https://api.github.com, https://gitlab.com/api/v4, https://codeberg.org/api/v1...). Guessed from -repository by default.")
+	flags.StringVar(&options.Git.Forge.Token, "forgeToken", "", "Token of the API of the forge, also used to clone and push https repositories.")
+	flags.StringVar(&options.Git.Forge.Project, "forgeProject", "", "owner/repository of -repository on the forge (or the full path of the project on GitLab). Guessed from -repository by default.")
+	flags.IntVar(&options.Count, "count", 1, fmt.Sprintf("How many favorites are shown, more than 1 shows a top list (at most %v). Not every provider supports lists.", MAX_COUNT))
+	flags.StringVar(&options.ListStyle, "listStyle", badge.BadgesList, "How a top list is shown when -count is more than 1. \"badges\" stacks one badge per song, \"table\" adds an html table.")
+	flags.StringVar(&options.entity, "entity", string(providers.EntityTrack), "What the badge shows: the favorite \"track\", \"artist\" or \"album\". Not every provider supports artists and albums.")
+	flags.StringVar(&options.fallback, "fallback", "", "Required if multiple providers are used (youtube and last.fm for example), each provider are separated by ','. The first one has higher priority over the lower one, if we can't find the favorite song from the first one, we take it from the other ones.")
+
+	// Every provider adds its own flags
+	available = providers.New()
+	for i := range available {
+		available[i].RegisterFlags(flags)
+	}
+	return
+}
+
+// Enable the configured providers and fill in the defaults, once every flag has been set
+func (options *Options) finish(available []providers.Provider) (err error) {
+	options.Providers, err = enabledProviders(available, options.fallback)
+	if err != nil {
+		return
+	}
+
+	switch providers.Entity(options.entity) {
+	case providers.EntityTrack, providers.EntityArtist, providers.EntityAlbum:
+	default:
+		return fmt.Errorf("Unknown entity \"%v\", \"%v\", \"%v\" and \"%v\" are the valid entities.", options.entity, providers.EntityTrack, providers.EntityArtist, providers.EntityAlbum)
+	}
+	err = providers.SetEntity(options.Providers, providers.Entity(options.entity))
+	if err != nil {
+		return
+	}
+	err = providers.CheckCount(options.Providers, options.Count)
+	if err != nil {
+		return
+	}
+
+	if options.SvgFilename == "" {
+		options.SvgFilename = path.Join(path.Dir(filepath.ToSlash(options.Filename)), badge.SVG_FILENAME)
+	}
+
+	return options.Validate()
+}
+
+// Check that the options that do not depend on a provider are correct
+func (options Options) Validate() error {
+	if options.Repository != "" && options.LocalPath != "" {
+		return errors.New("The repository and localPath flags can't be used together.")
+	}
+	if (options.Filename != "" && options.Repository == "" && options.LocalPath == "") || (options.Filename == "" && (options.Repository != "" || options.LocalPath != "")) {
+		return errors.New("If the file flag is given, the repository or localPath flag must also be added, and vice-versa.")
+	}
+	if options.Git.DryRun && options.Filename == "" {
+		return errors.New("The dryRun flag can only be used with the repository or localPath flag.")
+	}
+	if options.Git.PullRequestBranch != "" && options.Repository == "" {
+		return errors.New("The pullRequestBranch flag can only be used with the repository flag.")
+	}
+	if options.Git.ForgeApi && options.Repository == "" {
+		return errors.New("The forgeApi flag can only be used with the repository flag.")
+	}
+	if options.Git.ForgeApi && options.Git.PullRequestBranch != "" {
+		return errors.New("The forgeApi and pullRequestBranch flags can't be used together.")
+	}
+	if options.Git.PullRequestBranch != "" || options.Git.ForgeApi {
+		if err := options.Git.Forge.Validate(); err != nil {
+			return err
+		}
+	}
+	if (options.Git.AuthorName == "") != (options.Git.AuthorEmail == "") {
+		return errors.New("The authorName and authorEmail flags must be added together.")
+	}
+	if (options.Git.CommitterName == "") != (options.Git.CommitterEmail == "") {
+		return errors.New("The committerName and committerEmail flags must be added together.")
+	}
+	if options.Git.PushRetries < 0 {
+		return errors.New("The pushRetries flag can't be negative.")
+	}
+	if options.Git.SigningKeyFile != "" && options.Git.SigningKey != "" {
+		return errors.New("The signingKeyFile and signingKey flags can't be used together.")
+	}
+	if (options.Git.SigningKeyFile != "" || options.Git.SigningKey != "") && options.Git.ForgeApi {
+		return errors.New("The commits created with the forgeApi flag can't be signed, the forge creates them.")
+	}
+	if options.Git.Branch != "" && options.LocalPath != "" {
+		return errors.New("The branch flag can't be used with the localPath flag, the current branch is updated.")
+	}
+	if options.Git.Commit && options.LocalPath == "" {
+		return errors.New("The localCommit flag can only be used with the localPath flag.")
+	}
+	if options.Renderer != badge.ShieldsRenderer && options.Renderer != badge.SvgRenderer {
+		return fmt.Errorf("Unknown renderer \"%v\", \"%v\" and \"%v\" are the valid renderers.", options.Renderer, badge.ShieldsRenderer, badge.SvgRenderer)
+	}
+	if options.Count < 1 || options.Count > MAX_COUNT {
+		return fmt.Errorf("The count must be between 1 and %v.", MAX_COUNT)
+	}
+	if options.ListStyle != badge.BadgesList && options.ListStyle != badge.TableList {
+		return fmt.Errorf("Unknown list style \"%v\", \"%v\" and \"%v\" are the valid list styles.", options.ListStyle, badge.BadgesList, badge.TableList)
+	}
+	if options.Timeout <= 0 {
+		return errors.New("The timeout must be greater than 0.")
+	}
+	// With blocks, the providers are inside of the blocks
+	if len(options.Providers) == 0 && len(options.Blocks) == 0 {
+		return errors.New("No provider is enabled.")
+	}
+	return nil
+}
+
+// Give the argument that isn't a flag to the provider that takes it (see providers.ArgumentProvider)
+func setArgument(available []providers.Provider, argument string) error {
+	for i := range available {
+		if argument_provider, ok := available[i].(providers.ArgumentProvider); ok {
+			return argument_provider.SetArgument(argument)
+		}
+	}
+	return fmt.Errorf("Unexpected argument \"%v\", every option should be passed as a flag.", argument)
+}
+
+// Only keep the providers that the user has configured, check that they are correct
+// and sort them using the fallback order
+func enabledProviders(available []providers.Provider, fallback string) (enabled []providers.Provider, err error) {
+	for i := range available {
+		if !available[i].Configured() {
+			continue
+		}
+		if err = available[i].Validate(); err != nil {
+			return
+		}
+		enabled = append(enabled, available[i])
+	}
+
+	if len(enabled) == 0 {
+		err = fmt.Errorf("One of the providers %v must be configured (--lastFmUsername, --youtubeChannelId or --listenbrainzUsername for example), we have no idea where to take the favorite music from!", providers.Registered())
+		return
+	}
+
+	if len(enabled) == 1 {
+		return
+	}
+
+	fallback_order := strings.Split(fallback, ",")
+	if len(fallback_order) != len(enabled) {
+		err = errors.New("A fallback order must be given if there are multiple providers used (lastfm and youtube for example). For example, to have last.fm have a higher priority over youtube, use (--fallback \"lastfm,youtube\"")
+		return
+	}
+
+	for i := range fallback_order {
+		provider_type := providers.ProviderType(strings.ToLower(strings.TrimSpace(fallback_order[i])))
+		if !providers.IsRegistered(provider_type) {
+			err = fmt.Errorf("Unknown provider passed, \"%v\" is an unknown provider. %v are all valid providers.", fallback_order[i], providers.Registered())
+			return
+		}
+		err = providers.MoveProviderToIndex(enabled, provider_type, i)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+// Channel id of the youtube provider of the options
+func channelId(t *testing.T, options Options) string {
+	t.Helper()
+	for _, provider := range options.Providers {
+		if youtube, ok := provider.(*providers.YoutubeProvider); ok {
+			return youtube.ChannelId
+		}
+	}
+	t.Fatalf("The youtube provider isn't enabled: %v", options.Providers)
+	return ""
+}
+
+func TestParseArgument(t *testing.T) {
+	options, err := Parse([]string{"channel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := channelId(t, options); id != "channel" {
+		t.Errorf("Got the channel id %v", id)
+	}
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-youtubeChannelId", "flag", "channel"}, "supplied by both"},
+		{[]string{"one", "two"}, "Too many arguments"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.args)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%v: got the error %v, want one with %q", test.args, err, test.err)
+		}
+	}
+}
+
+func TestParseBlocks(t *testing.T) {
+	config_file := writeConfigFile(t, `
+repository: /tmp/repository.git
+filename: docs/README.md
+style: flat
+blocks:
+  - youtubeChannelId: first
+  - id: weekly
+    youtubeChannelId: second
+    style: plastic
+`)
+	options, err := Parse([]string{"-config", config_file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options.Blocks) != 2 {
+		t.Fatalf("Got %v blocks, want 2", len(options.Blocks))
+	}
+	first, weekly := options.Blocks[0], options.Blocks[1]
+	if channelId(t, first.Options) != "first" || channelId(t, weekly.Options) != "second" {
+		t.Error("The blocks don't have their own providers")
+	}
+	if first.Badge.Style != "flat" || weekly.Badge.Style != "plastic" {
+		t.Errorf("Got the styles %v and %v, want flat and plastic", first.Badge.Style, weekly.Badge.Style)
+	}
+	if first.SvgFilename != "docs/favorite_music_badge.svg" || weekly.SvgFilename != "docs/favorite_music_badge_weekly.svg" {
+		t.Errorf("Got the svg files %v and %v", first.SvgFilename, weekly.SvgFilename)
+	}
+}
+
+func TestParseBlocksProviders(t *testing.T) {
+	config_file := writeConfigFile(t, `
+repository: /tmp/repository.git
+filename: README.md
+lastFmUsername: user
+lastFmAPIKey: key
+blocks:
+  - id: weekly
+    lastFmPeriod: 7day
+  - id: listenbrainz
+    listenbrainzUsername: other
+  - id: alltime
+    listenbrainzUsername: other
+    fallback: [listenbrainz, lastfm]
+  - id: flat
+    style: flat
+`)
+	options, err := Parse([]string{"-config", config_file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The last.fm options only go to the blocks that use last.fm or don't set any provider
+	want := map[string][]providers.ProviderType{
+		"weekly":       {providers.LastFm},
+		"listenbrainz": {providers.Listenbrainz},
+		"alltime":      {providers.Listenbrainz, providers.LastFm},
+		"flat":         {providers.LastFm},
+	}
+	for _, block := range options.Blocks {
+		var names []providers.ProviderType
+		for _, provider := range block.Providers {
+			names = append(names, provider.Name())
+		}
+		if !slices.Equal(names, want[block.Id]) {
+			t.Errorf("%v: got the providers %v, want %v", block.Id, names, want[block.Id])
+		}
+		for _, provider := range block.Providers {
+			if lastfm, ok := provider.(*providers.LastFmProvider); ok && (lastfm.Username != "user" || lastfm.APIKey != "key") {
+				t.Errorf("%v: the last.fm options aren't inherited: %+v", block.Id, lastfm)
+			}
+		}
+	}
+}
+
+func TestParseBlocksErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		args    []string
+		err     string
+	}{
+		{
+			name:    "shared option inside of a block",
+			content: "blocks:\n  - youtubeChannelId: id\n    repository: other\n",
+			err:     `"repository" can't be changed inside of the block`,
+		},
+		{
+			name:    "block defined twice",
+			content: "blocks:\n  - youtubeChannelId: id\n  - youtubeChannelId: id\n",
+			err:     "defined twice",
+		},
+		{
+			name:    "block without provider",
+			content: "blocks:\n  - style: flat\n",
+			err:     "Inside of the block",
+		},
+		// The global options are checked even if every block is correct
+		{
+			name:    "invalid global option",
+			content: "pushRetries: -1\nblocks:\n  - youtubeChannelId: id\n",
+			err:     "pushRetries",
+		},
+		{
+			name:    "author without email",
+			content: "blocks:\n  - youtubeChannelId: id\n",
+			args:    []string{"-authorName", "name"},
+			err:     "authorName and authorEmail",
+		},
+		{
+			name:    "dry run without repository",
+			content: "blocks:\n  - youtubeChannelId: id\n",
+			args:    []string{"-dryRun"},
+			err:     "dryRun",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"-config", writeConfigFile(t, test.content)}, test.args...)
+			_, err := Parse(args)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Got the error %v, want one with %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseEntity(t *testing.T) {
+	options, err := Parse([]string{"-lastFmUsername", "user", "-lastFmAPIKey", "key", "-entity", "album"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastfm := options.Providers[0].(*providers.LastFmProvider); lastfm.Entity != providers.EntityAlbum {
+		t.Errorf("Got the entity %v, want album", lastfm.Entity)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"album of the takeout", []string{"-youtubeTakeout", "watch-history.json", "-entity", "album"}, "albums"},
+		{"recent last.fm mode", []string{"-lastFmUsername", "user", "-lastFmAPIKey", "key", "-lastFmMode", "recent", "-entity", "album"}, "\"top\" last.fm mode"},
+		{"unknown entity", []string{"-lastFmUsername", "user", "-lastFmAPIKey", "key", "-entity", "genre"}, "Unknown entity"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.args)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%v: got the error %v, want one with %q", test.name, err, test.err)
+		}
+	}
+}
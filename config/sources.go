@@ -0,0 +1,191 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Prefix of every environment variable that can be used instead of a flag
+const ENV_PREFIX = "FAVORITE_MUSIC_BADGE_"
+
+// Name of the environment variable of a flag, lastFmAPIKey becomes FAVORITE_MUSIC_BADGE_LAST_FM_API_KEY
+func EnvironmentVariable(flag_name string) string {
+	runes := []rune(flag_name)
+	var name strings.Builder
+	name.WriteString(ENV_PREFIX)
+	for i, r := range runes {
+		if r == '-' {
+			name.WriteRune('_')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			next_is_lower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && next_is_lower) {
+				name.WriteRune('_')
+			}
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
+
+// Environment variables without the prefix that were used before every flag could be set from
+// the environment, they are read after the one with the prefix
+var legacyEnvironmentVariables = map[string]string{
+	"lastFmAPIKey": "LAST_FM_API_KEY",
+}
+
+// Set every flag that wasn't given on the command line from the environment variables,
+// then from the config file
+//
+// The blocks of the config file are returned without being applied, see parseBlocks.
+func applyEnvironmentAndConfigFile(flags *flag.FlagSet) (blocks []map[string]string, err error) {
+	// Keyed by the environment variable, so that a deprecated flag (label-color) and its
+	// new name (labelColor) are the same option
+	already_set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		already_set[EnvironmentVariable(f.Name)] = true
+	})
+
+	flags.VisitAll(func(f *flag.Flag) {
+		variable := EnvironmentVariable(f.Name)
+		if err != nil || already_set[variable] {
+			return
+		}
+		read_from := variable
+		value, ok := os.LookupEnv(variable)
+		if legacy, has_legacy := legacyEnvironmentVariables[f.Name]; !ok && has_legacy {
+			read_from = legacy
+			value, ok = os.LookupEnv(legacy)
+		}
+		if !ok {
+			return
+		}
+		if set_err := flags.Set(f.Name, value); set_err != nil {
+			err = fmt.Errorf("Invalid value in the environment variable %v: %w", read_from, set_err)
+		}
+		already_set[variable] = true
+	})
+	if err != nil {
+		return
+	}
+
+	config_file := flags.Lookup("config").Value.String()
+	if config_file == "" {
+		return
+	}
+
+	values, blocks, err := readConfigFile(config_file)
+	if err != nil {
+		return
+	}
+
+	if err = checkDuplicateOptions(values, "inside of the config file "+config_file); err != nil {
+		return
+	}
+	for key, value := range values {
+		if flags.Lookup(key) == nil {
+			return nil, fmt.Errorf("Unknown option \"%v\" inside of the config file %v", key, config_file)
+		}
+		if already_set[EnvironmentVariable(key)] {
+			continue
+		}
+		if err = flags.Set(key, value); err != nil {
+			return nil, fmt.Errorf("Invalid value for \"%v\" inside of the config file %v: %w", key, config_file, err)
+		}
+	}
+
+	return
+}
+
+// Check that two keys of the config file are not the same option (label-color and labelColor),
+// otherwise the one that wins would depend on the order of the map
+func checkDuplicateOptions(values map[string]string, where string) error {
+	seen := map[string]string{}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		variable := EnvironmentVariable(key)
+		if other, exists := seen[variable]; exists {
+			return fmt.Errorf("\"%v\" and \"%v\" are the same option %v", other, key, where)
+		}
+		seen[variable] = key
+	}
+	return nil
+}
+
+// Read a YAML config file where every key is the name of a flag
+//
+// Lists are joined with ',' so that fallback can be written as a list (with a new line for the
+// options inside of listNewLineOptions). The blocks key is a list
+// of maps, where every key is also the name of a flag (except id).
+func readConfigFile(filename string) (values map[string]string, blocks []map[string]string, err error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	var raw map[string]any
+	err = yaml.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("While parsing the config file %v: %w", filename, err)
+	}
+
+	values = map[string]string{}
+	for key, value := range raw {
+		if key != BLOCKS_KEY {
+			values[key] = configValueToString(value, listSeparator(key))
+			continue
+		}
+
+		list, ok := value.([]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("\"%v\" must be a list inside of the config file %v", BLOCKS_KEY, filename)
+		}
+		for i := range list {
+			block, ok := list[i].(map[string]any)
+			if !ok {
+				return nil, nil, fmt.Errorf("Every block of \"%v\" must be a map inside of the config file %v", BLOCKS_KEY, filename)
+			}
+			block_values := map[string]string{}
+			for key, value := range block {
+				block_values[key] = configValueToString(value, listSeparator(key))
+			}
+			blocks = append(blocks, block_values)
+		}
+	}
+	return
+}
+
+// Options where every line is an item, because an item can contain ','
+var listNewLineOptions = []string{"commitTrailers"}
+
+// Separator of the items of a list inside of the option key
+func listSeparator(key string) string {
+	if slices.Contains(listNewLineOptions, key) {
+		return "\n"
+	}
+	return ","
+}
+
+// Convert a value parsed from YAML to the string form the flag expects, the items of a list are joined with separator
+func configValueToString(value any, separator string) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(value))
+		for i := range value {
+			parts[i] = configValueToString(value[i], separator)
+		}
+		return strings.Join(parts, separator)
+	default:
+		return fmt.Sprint(value)
+	}
+}
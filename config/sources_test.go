@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+func TestEnvironmentVariable(t *testing.T) {
+	tests := []struct {
+		flag_name string
+		variable  string
+	}{
+		{"repository", "FAVORITE_MUSIC_BADGE_REPOSITORY"},
+		{"lastFmUsername", "FAVORITE_MUSIC_BADGE_LAST_FM_USERNAME"},
+		{"lastFmAPIKey", "FAVORITE_MUSIC_BADGE_LAST_FM_API_KEY"},
+		{"youtubeChannelId", "FAVORITE_MUSIC_BADGE_YOUTUBE_CHANNEL_ID"},
+		{"message-color", "FAVORITE_MUSIC_BADGE_MESSAGE_COLOR"},
+		{"spotify2Id", "FAVORITE_MUSIC_BADGE_SPOTIFY2_ID"},
+		{"h", "FAVORITE_MUSIC_BADGE_H"},
+	}
+	for _, test := range tests {
+		if variable := EnvironmentVariable(test.flag_name); variable != test.variable {
+			t.Errorf("%v: got %v, want %v", test.flag_name, variable, test.variable)
+		}
+	}
+}
+
+// Write the config file inside of a temporary directory, returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	config_file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config_file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return config_file
+}
+
+func TestEnvironmentAndConfigFilePrecedence(t *testing.T) {
+	config_file := writeConfigFile(t, `
+youtubeChannelId: from-config
+style: flat
+label-color: red
+messageColor: blue
+`)
+	t.Setenv("FAVORITE_MUSIC_BADGE_STYLE", "plastic")
+	t.Setenv("FAVORITE_MUSIC_BADGE_LABEL_COLOR", "green")
+
+	options, err := Parse([]string{"-config", config_file, "-labelColor", "yellow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		// The flag wins over the environment and the config file, even with the deprecated name
+		{"labelColor", options.Badge.LabelColor, "yellow"},
+		// The environment wins over the config file
+		{"style", options.Badge.Style, "plastic"},
+		// The config file wins over the default
+		{"messageColor", options.Badge.MessageColor, "blue"},
+		// Default
+		{"logo", options.Badge.Logo, "youtube-music"},
+	}
+	for _, test := range tests {
+		if test.value != test.want {
+			t.Errorf("%v: got %v, want %v", test.name, test.value, test.want)
+		}
+	}
+	if len(options.Providers) != 1 || options.Providers[0].Name() != "youtube" {
+		t.Errorf("Got the providers %v, want youtube", options.Providers)
+	}
+}
+
+// API key of the last.fm provider of the options
+func lastFmAPIKey(t *testing.T, options Options) string {
+	t.Helper()
+	for _, provider := range options.Providers {
+		if lastfm, ok := provider.(*providers.LastFmProvider); ok {
+			return lastfm.APIKey
+		}
+	}
+	t.Fatalf("The last.fm provider isn't enabled: %v", options.Providers)
+	return ""
+}
+
+func TestLegacyEnvironmentVariable(t *testing.T) {
+	config_file := writeConfigFile(t, "lastFmUsername: user\nlastFmAPIKey: from-config\n")
+
+	// LAST_FM_API_KEY wins over the config file
+	t.Setenv("LAST_FM_API_KEY", "legacy")
+	options, err := Parse([]string{"-config", config_file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := lastFmAPIKey(t, options); key != "legacy" {
+		t.Errorf("Got the key %v, want the one of LAST_FM_API_KEY", key)
+	}
+
+	// But not over the variable with the prefix, nor the flag
+	t.Setenv("FAVORITE_MUSIC_BADGE_LAST_FM_API_KEY", "prefixed")
+	options, err = Parse([]string{"-config", config_file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := lastFmAPIKey(t, options); key != "prefixed" {
+		t.Errorf("Got the key %v, want the one of FAVORITE_MUSIC_BADGE_LAST_FM_API_KEY", key)
+	}
+	options, err = Parse([]string{"-config", config_file, "-lastFmAPIKey", "flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := lastFmAPIKey(t, options); key != "flag" {
+		t.Errorf("Got the key %v, want the one of the flag", key)
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{"unknown: value\n", `Unknown option "unknown"`},
+		{"youtubeChannelId: id\ntimeout: soon\n", `Invalid value for "timeout"`},
+		{"blocks: value\n", "must be a list"},
+		{"blocks:\n  - value\n", "must be a map"},
+		{": not yaml\n\t", "While parsing the config file"},
+		// Which one would win would depend on the order of the map
+		{"youtubeChannelId: id\nlabel-color: red\nlabelColor: blue\n", `"label-color" and "labelColor" are the same option`},
+		{"blocks:\n  - youtubeChannelId: id\n    message-color: red\n    messageColor: blue\n", `"message-color" and "messageColor" are the same option inside of the block`},
+	}
+	for _, test := range tests {
+		_, err := Parse([]string{"-config", writeConfigFile(t, test.content)})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got the error %v, want one with %q", test.content, err, test.err)
+		}
+	}
+
+	t.Setenv("FAVORITE_MUSIC_BADGE_TIMEOUT", "soon")
+	_, err := Parse([]string{"-youtubeChannelId", "id"})
+	if err == nil || !strings.Contains(err.Error(), "FAVORITE_MUSIC_BADGE_TIMEOUT") {
+		t.Errorf("Got the error %v, want one about the environment variable", err)
+	}
+}
+
+func TestConfigValueToString(t *testing.T) {
+	tests := []struct {
+		value     any
+		separator string
+		want      string
+	}{
+		{nil, ",", ""},
+		{"text", ",", "text"},
+		{3, ",", "3"},
+		{true, ",", "true"},
+		{[]any{"lastfm", "youtube"}, ",", "lastfm,youtube"},
+		{[]any{"[skip ci]", "Co-authored-by: A, B <a@example.com>"}, "\n", "[skip ci]\nCo-authored-by: A, B <a@example.com>"},
+	}
+	for _, test := range tests {
+		if value := configValueToString(test.value, test.separator); value != test.want {
+			t.Errorf("%v: got %q, want %q", test.value, value, test.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Fake forge that keeps the files of the main branch in memory, with the paths and the json of the three APIs
+type fakeContents struct {
+	sync.Mutex
+	forge_type ForgeType
+	// Path to content
+	files map[string]string
+	// Path to sha, changed on every update
+	shas    map[string]string
+	commits int
+	// Method and body of every update
+	updates []fakeUpdate
+}
+
+type fakeUpdate struct {
+	method string
+	body   map[string]any
+}
+
+func newFakeContents(forge_type ForgeType, files map[string]string) *fakeContents {
+	fake := &fakeContents{forge_type: forge_type, files: map[string]string{}, shas: map[string]string{}}
+	for path, content := range files {
+		fake.commit(path, content)
+	}
+	return fake
+}
+
+func (fake *fakeContents) commit(path string, content string) {
+	fake.commits++
+	fake.files[path] = content
+	fake.shas[path] = fmt.Sprintf("sha-%v", fake.commits)
+}
+
+// Path of the file inside of the request, empty if it isn't a request for a file
+func (fake *fakeContents) filePath(r *http.Request) string {
+	prefix := "/repos/owner/name/contents/"
+	if fake.forge_type == GitLab {
+		prefix = "/projects/owner%2Fname/repository/files/"
+	}
+	escaped, found := strings.CutPrefix(r.URL.EscapedPath(), prefix)
+	if !found {
+		return ""
+	}
+	path, _ := url.PathUnescape(escaped)
+	return path
+}
+
+func (fake *fakeContents) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.Lock()
+		defer fake.Unlock()
+
+		if fake.forge_type == GitLab && r.URL.EscapedPath() == "/projects/owner%2Fname" {
+			json.NewEncoder(w).Encode(map[string]string{"default_branch": "main"})
+			return
+		}
+		path := fake.filePath(r)
+		if path == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			if ref := r.URL.Query().Get("ref"); ref != "main" && !(ref == "" && fake.forge_type != GitLab) {
+				http.NotFound(w, r)
+				return
+			}
+			content, ok := fake.files[path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			// GitHub splits the base64 in lines
+			encoded := base64.StdEncoding.EncodeToString([]byte(content))
+			if fake.forge_type == GitHub {
+				var lines []string
+				for len(encoded) > 8 {
+					lines = append(lines, encoded[:8])
+					encoded = encoded[8:]
+				}
+				encoded = strings.Join(append(lines, encoded), "\n") + "\n"
+			}
+			json.NewEncoder(w).Encode(map[string]string{"content": encoded, "sha": fake.shas[path], "last_commit_id": fake.shas[path]})
+			return
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Invalid body: %v", err)
+		}
+		fake.updates = append(fake.updates, fakeUpdate{r.Method, body})
+
+		sha_key := "sha"
+		if fake.forge_type == GitLab {
+			sha_key = "last_commit_id"
+		}
+		sha, _ := body[sha_key].(string)
+		if sha != fake.shas[path] {
+			http.Error(w, `{"message": "the file has changed"}`, http.StatusConflict)
+			return
+		}
+		content, err := base64.StdEncoding.DecodeString(body["content"].(string))
+		if err != nil {
+			t.Errorf("Invalid content: %v", err)
+		}
+		fake.commit(path, string(content))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	})
+}
+
+func TestContents(t *testing.T) {
+	tests := []struct {
+		forge_type ForgeType
+		// Methods of the creation and of the update
+		create string
+		update string
+	}{
+		{GitHub, http.MethodPut, http.MethodPut},
+		{GitLab, http.MethodPost, http.MethodPut},
+		{Forgejo, http.MethodPost, http.MethodPut},
+	}
+	for _, test := range tests {
+		t.Run(string(test.forge_type), func(t *testing.T) {
+			fake := newFakeContents(test.forge_type, map[string]string{"docs/README.md": "old readme"})
+			server := httptest.NewServer(fake.handler(t))
+			defer server.Close()
+			ctx := context.Background()
+
+			forge, err := New(Options{Type: string(test.forge_type), Url: server.URL, Token: "token", Project: "owner/name"}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := forge.GetFile(ctx, "docs/README.md", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(file.Content) != "old readme" || file.Sha != "sha-1" {
+				t.Errorf("Got %q and %v, want the old readme", file.Content, file.Sha)
+			}
+			if _, err = forge.GetFile(ctx, "docs/badge file.svg", "main"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Got %v for a missing file, want ErrNotFound", err)
+			}
+
+			// Create a file
+			author := Identity{Name: "Author", Email: "author@example.com"}
+			err = forge.UpdateFile(ctx, FileUpdate{Path: "docs/badge file.svg", Content: []byte("<svg></svg>"), Message: "badge", Author: author})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fake.files["docs/badge file.svg"] != "<svg></svg>" {
+				t.Errorf("The file wasn't created: %v", fake.files)
+			}
+
+			// Update it with the sha that was read
+			err = forge.UpdateFile(ctx, FileUpdate{Path: "docs/README.md", Branch: "main", Content: []byte("new readme"), Sha: file.Sha, Message: "readme"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fake.files["docs/README.md"] != "new readme" {
+				t.Errorf("The file wasn't updated: %q", fake.files["docs/README.md"])
+			}
+
+			// The sha is now outdated, the update is refused instead of overwriting the file
+			err = forge.UpdateFile(ctx, FileUpdate{Path: "docs/README.md", Content: []byte("newer readme"), Sha: file.Sha, Message: "readme"})
+			if err == nil || !strings.Contains(err.Error(), "the file has changed") {
+				t.Errorf("Got %v, want the conflict", err)
+			}
+
+			if len(fake.updates) != 3 || fake.updates[0].method != test.create || fake.updates[1].method != test.update {
+				t.Fatalf("Got the updates %+v, want %v then %v", fake.updates, test.create, test.update)
+			}
+			created := fake.updates[0].body
+			switch test.forge_type {
+			case GitLab:
+				if created["commit_message"] != "badge" || created["author_email"] != author.Email || created["branch"] != "main" {
+					t.Errorf("Got the body %v", created)
+				}
+			default:
+				if created["message"] != "badge" || fmt.Sprint(created["author"]) != "map[email:author@example.com name:Author]" {
+					t.Errorf("Got the body %v", created)
+				}
+				if _, ok := created["committer"]; ok {
+					t.Errorf("The committer was sent without being set: %v", created)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Forges are the websites hosting the git repositories (GitHub, GitLab, Forgejo...), their REST API
+// is used to open the pull requests and to update files without cloning
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Type of a forge, decides which API is used
+type ForgeType string
+
+const (
+	GitHub ForgeType = "github"
+	GitLab ForgeType = "gitlab"
+	// Also used for Gitea and Codeberg, they have the same API
+	Forgejo ForgeType = "forgejo"
+)
+
+// Every forge type that can be used
+var Types = []ForgeType{GitHub, GitLab, Forgejo}
+
+// How to reach the API of the forge
+type Options struct {
+	Type string
+	// Base url of the API (https://api.github.com, https://gitlab.com/api/v4, https://codeberg.org/api/v1...),
+	// guessed from the host of the repository when empty
+	Url   string
+	Token string
+	// owner/repository (or the full path of the project on GitLab), guessed from the repository when empty
+	Project string
+}
+
+// A pull request (merge request on GitLab) from the Head branch to the Base branch
+type PullRequest struct {
+	Head  string
+	Base  string
+	Title string
+	Body  string
+}
+
+// A file read with the API of a forge
+type File struct {
+	Content []byte
+	// Version of the file (the sha of the blob, or of the last commit that changed it on GitLab),
+	// updating the file fails if it has changed since
+	Sha string
+}
+
+// A file to create or update with the API of a forge
+type FileUpdate struct {
+	Path string
+	// Branch where the commit is created, the default branch when empty
+	Branch  string
+	Content []byte
+	// Sha of the File that was read, empty to create the file
+	Sha     string
+	Message string
+	// Chosen by the forge when they are empty
+	Author    Identity
+	Committer Identity
+}
+
+// Author or committer of a commit
+type Identity struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Returned when the file doesn't exist
+var ErrNotFound = errors.New("Not found")
+
+// The API of a forge
+type Forge interface {
+	// Open the pull request, if one is already open from the same head to the same base it is reused
+	// instead of opening another one
+	//
+	// Returns the link to the pull request and whether it was already open.
+	OpenPullRequest(ctx context.Context, pull_request PullRequest) (link string, existed bool, err error)
+	// Read the file from the branch (the default branch when empty), ErrNotFound if it doesn't exist
+	GetFile(ctx context.Context, path string, branch string) (file File, err error)
+	// Create or update a file, in a new commit
+	UpdateFile(ctx context.Context, update FileUpdate) (err error)
+}
+
+// Check that the options are correct, without guessing anything
+func (options Options) Validate() error {
+	if !slices.Contains(Types, ForgeType(options.Type)) {
+		return fmt.Errorf("Unknown forge \"%v\", the valid forges are %v.", options.Type, Types)
+	}
+	if options.Token == "" {
+		return errors.New("A token is needed to use the API of the forge.")
+	}
+	return nil
+}
+
+// Create the client of the forge, the url and the project are guessed from the repository
+// (git@codeberg.org:owner/repository.git or https://codeberg.org/owner/repository.git) if they are not set
+func New(options Options, repository string) (forge Forge, err error) {
+	err = options.Validate()
+	if err != nil {
+		return
+	}
+
+	if options.Url == "" || options.Project == "" {
+		var endpoint *transport.Endpoint
+		endpoint, err = transport.NewEndpoint(repository)
+		if err != nil {
+			return
+		}
+		if options.Url == "" {
+			options.Url = apiUrl(ForgeType(options.Type), endpoint.Host)
+		}
+		if options.Project == "" {
+			options.Project = strings.TrimSuffix(strings.Trim(endpoint.Path, "/"), ".git")
+		}
+	}
+	options.Url = strings.TrimSuffix(options.Url, "/")
+
+	switch ForgeType(options.Type) {
+	case GitHub:
+		return newGitHub(options)
+	case GitLab:
+		return &gitLab{options}, nil
+	default:
+		return newForgejo(options)
+	}
+}
+
+// Base url of the API of a forge hosted on that host
+func apiUrl(forge_type ForgeType, host string) string {
+	switch forge_type {
+	case GitHub:
+		if host == "github.com" {
+			return "https://api.github.com"
+		}
+		// GitHub Enterprise Server
+		return fmt.Sprintf("https://%v/api/v3", host)
+	case GitLab:
+		return fmt.Sprintf("https://%v/api/v4", host)
+	default:
+		return fmt.Sprintf("https://%v/api/v1", host)
+	}
+}
+
+// Split owner/repository, GitHub and Forgejo don't have sub groups like GitLab
+func splitProject(project string) (owner string, name string, err error) {
+	owner, name, found := strings.Cut(project, "/")
+	if !found || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("The project \"%v\" must be owner/repository.", project)
+	}
+	return
+}
+
+// Escape every part of a path inside of the repository, the slashes are kept
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// Send a request to the API, body is encoded as json if it isn't nil and the response is decoded into v
+//
+// Every status other than 2xx is an error, with the message of the forge inside of it.
+func sendRequest(ctx context.Context, method string, request string, headers map[string]string, body any, v any) (err error) {
+	var reader io.Reader
+	if body != nil {
+		var content []byte
+		content, err = json.Marshal(body)
+		if err != nil {
+			return
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, request, reader)
+	if err != nil {
+		return
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%v %v: %w", method, request, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		content, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%v %v failed with status %v: %v", method, request, resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,204 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// Fake forge that keeps the pull requests in memory, with the paths and the json of the three APIs
+type fakePullRequests struct {
+	sync.Mutex
+	created []map[string]string
+	// Escaped path of every request
+	requests []string
+	// The pull requests are closed as soon as they are created, like if they were merged
+	closed bool
+}
+
+func (fake *fakePullRequests) handler(t *testing.T, forge_type ForgeType) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.Lock()
+		defer fake.Unlock()
+		fake.requests = append(fake.requests, r.URL.EscapedPath())
+
+		if r.Method == http.MethodPost {
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Invalid body: %v", err)
+			}
+			fake.created = append(fake.created, body)
+			w.WriteHeader(http.StatusCreated)
+			link := fmt.Sprintf("https://forge.test/pulls/%v", len(fake.created))
+			json.NewEncoder(w).Encode(map[string]string{"html_url": link, "web_url": link})
+			return
+		}
+
+		state := "open"
+		if fake.closed {
+			state = "closed"
+		}
+		var pull_requests []map[string]any
+		for i, body := range fake.created {
+			link := fmt.Sprintf("https://forge.test/pulls/%v", i+1)
+			head, base := body["head"], body["base"]
+			if forge_type == GitLab {
+				head, base = body["source_branch"], body["target_branch"]
+			}
+			pull_requests = append(pull_requests, map[string]any{"html_url": link, "web_url": link, "state": state, "head": head, "base": base})
+		}
+
+		// Forgejo looks the pull request up by its branches: /pulls/{base}/{head}
+		if forge_type == Forgejo {
+			for _, pull_request := range slices.Backward(pull_requests) {
+				if r.URL.Path == fmt.Sprintf("/repos/owner/name/pulls/%v/%v", pull_request["base"], pull_request["head"]) {
+					json.NewEncoder(w).Encode(pull_request)
+					return
+				}
+			}
+			http.NotFound(w, r)
+			return
+		}
+
+		// GitHub and GitLab filter the open ones
+		open := []map[string]any{}
+		if !fake.closed {
+			open = append(open, pull_requests...)
+		}
+		json.NewEncoder(w).Encode(open)
+	})
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	tests := []struct {
+		forge_type ForgeType
+		head       string
+		// Paths of the search and of the creation of the pull requests on the fake server
+		search string
+		create string
+	}{
+		{GitHub, "favorite_music_badge", "/repos/owner/name/pulls", "/repos/owner/name/pulls"},
+		{GitLab, "favorite_music_badge", "/projects/owner%2Fname/merge_requests", "/projects/owner%2Fname/merge_requests"},
+		{Forgejo, "favorite_music_badge", "/repos/owner/name/pulls/main/favorite_music_badge", "/repos/owner/name/pulls"},
+		{Forgejo, "badges/favorite music", "/repos/owner/name/pulls/main/badges/favorite%20music", "/repos/owner/name/pulls"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v from %v", test.forge_type, test.head), func(t *testing.T) {
+			fake := &fakePullRequests{}
+			server := httptest.NewServer(fake.handler(t, test.forge_type))
+			defer server.Close()
+
+			forge, err := New(Options{Type: string(test.forge_type), Url: server.URL, Token: "token", Project: "owner/name"}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			pull_request := PullRequest{Head: test.head, Base: "main", Title: "title", Body: "body"}
+
+			link, existed, err := forge.OpenPullRequest(context.Background(), pull_request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if existed || link != "https://forge.test/pulls/1" {
+				t.Errorf("First run: got %v (existed %v), want a new pull request", link, existed)
+			}
+
+			// The second run reuses the pull request
+			link, existed, err = forge.OpenPullRequest(context.Background(), pull_request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !existed || link != "https://forge.test/pulls/1" {
+				t.Errorf("Second run: got %v (existed %v), want the first pull request", link, existed)
+			}
+			if len(fake.created) != 1 {
+				t.Errorf("Created %v pull requests, want 1", len(fake.created))
+			}
+			want := []string{test.search, test.create, test.search}
+			if !slices.Equal(fake.requests, want) {
+				t.Errorf("Got the requests %v, want %v", fake.requests, want)
+			}
+		})
+	}
+}
+
+func TestOpenPullRequestClosed(t *testing.T) {
+	// The previous pull request was merged, a new one is opened
+	for _, forge_type := range Types {
+		fake := &fakePullRequests{closed: true}
+		server := httptest.NewServer(fake.handler(t, forge_type))
+
+		forge, err := New(Options{Type: string(forge_type), Url: server.URL, Token: "token", Project: "owner/name"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for run := range 2 {
+			link, existed, err := forge.OpenPullRequest(context.Background(), PullRequest{Head: "favorite_music_badge", Base: "main"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if existed || link != fmt.Sprintf("https://forge.test/pulls/%v", run+1) {
+				t.Errorf("%v: got %v (existed %v), want a new pull request", forge_type, link, existed)
+			}
+		}
+		server.Close()
+	}
+}
+
+func TestNewGuessesFromRepository(t *testing.T) {
+	tests := []struct {
+		forge_type ForgeType
+		repository string
+		url        string
+		project    string
+	}{
+		{GitHub, "git@github.com:owner/name.git", "https://api.github.com", "owner/name"},
+		{GitHub, "https://github.example.com/owner/name", "https://github.example.com/api/v3", "owner/name"},
+		{GitLab, "ssh://git@gitlab.com/group/sub/name.git", "https://gitlab.com/api/v4", "group/sub/name"},
+		{Forgejo, "https://codeberg.org/owner/name.git", "https://codeberg.org/api/v1", "owner/name"},
+	}
+	for _, test := range tests {
+		forge, err := New(Options{Type: string(test.forge_type), Token: "token"}, test.repository)
+		if err != nil {
+			t.Errorf("%v: %v", test.repository, err)
+			continue
+		}
+		var options Options
+		switch forge := forge.(type) {
+		case *gitHub:
+			options = forge.Options
+		case *gitLab:
+			options = forge.Options
+		case *forgejo:
+			options = forge.Options
+		}
+		if options.Url != test.url || options.Project != test.project {
+			t.Errorf("%v: got %v and %v, want %v and %v", test.repository, options.Url, options.Project, test.url, test.project)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		options Options
+		valid   bool
+	}{
+		{Options{Type: "github", Token: "token"}, true},
+		{Options{Type: "gitea", Token: "token"}, false},
+		{Options{Type: "gitlab"}, false},
+	}
+	for _, test := range tests {
+		if err := test.options.Validate(); (err == nil) != test.valid {
+			t.Errorf("%+v: got %v, want valid %v", test.options, err, test.valid)
+		}
+	}
+
+	if _, err := New(Options{Type: "github", Token: "token", Project: "group/sub/name", Url: "http://localhost"}, ""); err == nil {
+		t.Error("A GitHub project with sub groups must be refused")
+	}
+}
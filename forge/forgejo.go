@@ -0,0 +1,107 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// https://codeberg.org/api/swagger, Gitea has the same API
+type forgejo struct {
+	Options
+	owner string
+	name  string
+}
+
+type forgejoFile struct {
+	Content string `json:"content"`
+	Sha     string `json:"sha"`
+}
+
+type forgejoPullRequest struct {
+	HtmlUrl string `json:"html_url"`
+	// open or closed, merged pull requests are closed
+	State string `json:"state"`
+}
+
+func newForgejo(options Options) (forge *forgejo, err error) {
+	owner, name, err := splitProject(options.Project)
+	if err != nil {
+		return
+	}
+	return &forgejo{options, owner, name}, nil
+}
+
+func (forge *forgejo) headers() map[string]string {
+	return map[string]string{"Authorization": "token " + forge.Token}
+}
+
+func (forge *forgejo) repositoryUrl() string {
+	return fmt.Sprintf("%v/repos/%v/%v", forge.Url, url.PathEscape(forge.owner), url.PathEscape(forge.name))
+}
+
+func (forge *forgejo) OpenPullRequest(ctx context.Context, pull_request PullRequest) (link string, existed bool, err error) {
+	pulls := forge.repositoryUrl() + "/pulls"
+
+	// Look the pull request up by its branches, paging through the open ones would depend on the
+	// page size of the server. The one that is found can be closed, then another one is opened.
+	var existing forgejoPullRequest
+	err = sendRequest(ctx, http.MethodGet, fmt.Sprintf("%v/%v/%v", pulls, url.PathEscape(pull_request.Base), escapePath(pull_request.Head)), forge.headers(), nil, &existing)
+	if err == nil && existing.State == "open" {
+		return existing.HtmlUrl, true, nil
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return
+	}
+
+	var created forgejoPullRequest
+	err = sendRequest(ctx, http.MethodPost, pulls, forge.headers(), map[string]string{
+		"head":  pull_request.Head,
+		"base":  pull_request.Base,
+		"title": pull_request.Title,
+		"body":  pull_request.Body,
+	}, &created)
+	return created.HtmlUrl, false, err
+}
+
+func (forge *forgejo) GetFile(ctx context.Context, path string, branch string) (file File, err error) {
+	request := forge.repositoryUrl() + "/contents/" + escapePath(path)
+	if branch != "" {
+		request += "?ref=" + url.QueryEscape(branch)
+	}
+
+	var content forgejoFile
+	err = sendRequest(ctx, http.MethodGet, request, forge.headers(), nil, &content)
+	if err != nil {
+		return
+	}
+	file.Sha = content.Sha
+	file.Content, err = base64.StdEncoding.DecodeString(content.Content)
+	return
+}
+
+// A new file is created with POST, an existing one is updated with PUT
+func (forge *forgejo) UpdateFile(ctx context.Context, update FileUpdate) (err error) {
+	body := map[string]any{
+		"message": update.Message,
+		"content": base64.StdEncoding.EncodeToString(update.Content),
+	}
+	if update.Author.Name != "" {
+		body["author"] = update.Author
+	}
+	if update.Committer.Name != "" {
+		body["committer"] = update.Committer
+	}
+	method := http.MethodPost
+	if update.Sha != "" {
+		body["sha"] = update.Sha
+		method = http.MethodPut
+	}
+	if update.Branch != "" {
+		body["branch"] = update.Branch
+	}
+	return sendRequest(ctx, method, forge.repositoryUrl()+"/contents/"+escapePath(update.Path), forge.headers(), body, nil)
+}
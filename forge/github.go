@@ -0,0 +1,110 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// https://docs.github.com/en/rest/pulls/pulls and https://docs.github.com/en/rest/repos/contents
+type gitHub struct {
+	Options
+	owner string
+	name  string
+}
+
+type gitHubFile struct {
+	// Base64 with new lines, that the decoder ignores
+	Content string `json:"content"`
+	Sha     string `json:"sha"`
+}
+
+type gitHubPullRequest struct {
+	HtmlUrl string `json:"html_url"`
+}
+
+func newGitHub(options Options) (forge *gitHub, err error) {
+	owner, name, err := splitProject(options.Project)
+	if err != nil {
+		return
+	}
+	return &gitHub{options, owner, name}, nil
+}
+
+func (forge *gitHub) headers() map[string]string {
+	return map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"Authorization":        "Bearer " + forge.Token,
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+}
+
+func (forge *gitHub) repositoryUrl() string {
+	return fmt.Sprintf("%v/repos/%v/%v", forge.Url, url.PathEscape(forge.owner), url.PathEscape(forge.name))
+}
+
+func (forge *gitHub) OpenPullRequest(ctx context.Context, pull_request PullRequest) (link string, existed bool, err error) {
+	pulls := forge.repositoryUrl() + "/pulls"
+
+	// The head has to be prefixed by the owner of the branch
+	query := url.Values{
+		"state": {"open"},
+		"head":  {forge.owner + ":" + pull_request.Head},
+		"base":  {pull_request.Base},
+	}
+	var open []gitHubPullRequest
+	err = sendRequest(ctx, http.MethodGet, pulls+"?"+query.Encode(), forge.headers(), nil, &open)
+	if err != nil {
+		return
+	}
+	if len(open) > 0 {
+		return open[0].HtmlUrl, true, nil
+	}
+
+	var created gitHubPullRequest
+	err = sendRequest(ctx, http.MethodPost, pulls, forge.headers(), map[string]string{
+		"head":  pull_request.Head,
+		"base":  pull_request.Base,
+		"title": pull_request.Title,
+		"body":  pull_request.Body,
+	}, &created)
+	return created.HtmlUrl, false, err
+}
+
+func (forge *gitHub) GetFile(ctx context.Context, path string, branch string) (file File, err error) {
+	request := forge.repositoryUrl() + "/contents/" + escapePath(path)
+	if branch != "" {
+		request += "?ref=" + url.QueryEscape(branch)
+	}
+
+	var content gitHubFile
+	err = sendRequest(ctx, http.MethodGet, request, forge.headers(), nil, &content)
+	if err != nil {
+		return
+	}
+	file.Sha = content.Sha
+	file.Content, err = base64.StdEncoding.DecodeString(content.Content)
+	return
+}
+
+func (forge *gitHub) UpdateFile(ctx context.Context, update FileUpdate) (err error) {
+	body := map[string]any{
+		"message": update.Message,
+		"content": base64.StdEncoding.EncodeToString(update.Content),
+	}
+	if update.Author.Name != "" {
+		body["author"] = update.Author
+	}
+	if update.Committer.Name != "" {
+		body["committer"] = update.Committer
+	}
+	if update.Sha != "" {
+		body["sha"] = update.Sha
+	}
+	if update.Branch != "" {
+		body["branch"] = update.Branch
+	}
+	return sendRequest(ctx, http.MethodPut, forge.repositoryUrl()+"/contents/"+escapePath(update.Path), forge.headers(), body, nil)
+}
@@ -0,0 +1,121 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// https://docs.gitlab.com/api/merge_requests/ and https://docs.gitlab.com/api/repository_files/
+type gitLab struct {
+	Options
+}
+
+type gitLabProject struct {
+	DefaultBranch string `json:"default_branch"`
+}
+
+type gitLabFile struct {
+	Content      string `json:"content"`
+	LastCommitId string `json:"last_commit_id"`
+}
+
+type gitLabMergeRequest struct {
+	WebUrl string `json:"web_url"`
+}
+
+func (forge *gitLab) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + forge.Token}
+}
+
+// Url of the project, the path of the project (group/subgroup/name) is used as its id
+func (forge *gitLab) projectUrl() string {
+	return fmt.Sprintf("%v/projects/%v", forge.Url, url.PathEscape(forge.Project))
+}
+
+func (forge *gitLab) OpenPullRequest(ctx context.Context, pull_request PullRequest) (link string, existed bool, err error) {
+	merge_requests := forge.projectUrl() + "/merge_requests"
+
+	query := url.Values{
+		"state":         {"opened"},
+		"source_branch": {pull_request.Head},
+		"target_branch": {pull_request.Base},
+	}
+	var open []gitLabMergeRequest
+	err = sendRequest(ctx, http.MethodGet, merge_requests+"?"+query.Encode(), forge.headers(), nil, &open)
+	if err != nil {
+		return
+	}
+	if len(open) > 0 {
+		return open[0].WebUrl, true, nil
+	}
+
+	var created gitLabMergeRequest
+	err = sendRequest(ctx, http.MethodPost, merge_requests, forge.headers(), map[string]string{
+		"source_branch": pull_request.Head,
+		"target_branch": pull_request.Base,
+		"title":         pull_request.Title,
+		"description":   pull_request.Body,
+	}, &created)
+	return created.WebUrl, false, err
+}
+
+// The branch is needed by every request about files, use the default branch when it is empty
+func (forge *gitLab) branch(ctx context.Context, branch string) (string, error) {
+	if branch != "" {
+		return branch, nil
+	}
+	var project gitLabProject
+	err := sendRequest(ctx, http.MethodGet, forge.projectUrl(), forge.headers(), nil, &project)
+	return project.DefaultBranch, err
+}
+
+// Url of a file, the slashes of its path are also escaped
+func (forge *gitLab) fileUrl(path string) string {
+	return forge.projectUrl() + "/repository/files/" + url.PathEscape(path)
+}
+
+// The sha of the file is the last commit that changed it, GitLab checks it with last_commit_id
+func (forge *gitLab) GetFile(ctx context.Context, path string, branch string) (file File, err error) {
+	branch, err = forge.branch(ctx, branch)
+	if err != nil {
+		return
+	}
+
+	var content gitLabFile
+	err = sendRequest(ctx, http.MethodGet, forge.fileUrl(path)+"?ref="+url.QueryEscape(branch), forge.headers(), nil, &content)
+	if err != nil {
+		return
+	}
+	file.Sha = content.LastCommitId
+	file.Content, err = base64.StdEncoding.DecodeString(content.Content)
+	return
+}
+
+// A new file is created with POST, an existing one is updated with PUT
+func (forge *gitLab) UpdateFile(ctx context.Context, update FileUpdate) (err error) {
+	branch, err := forge.branch(ctx, update.Branch)
+	if err != nil {
+		return
+	}
+
+	body := map[string]string{
+		"branch":         branch,
+		"commit_message": update.Message,
+		"encoding":       "base64",
+		"content":        base64.StdEncoding.EncodeToString(update.Content),
+	}
+	// GitLab has no committer
+	if update.Author.Name != "" {
+		body["author_name"] = update.Author.Name
+		body["author_email"] = update.Author.Email
+	}
+	method := http.MethodPost
+	if update.Sha != "" {
+		body["last_commit_id"] = update.Sha
+		method = http.MethodPut
+	}
+	return sendRequest(ctx, method, forge.fileUrl(update.Path), forge.headers(), body, nil)
+}
@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Get the favorite music from a list of providers, we try the first provider,
+// then the second, etc.
+func GetFavorite(providers []Provider, timeout time.Duration) (song Song, err error) {
+	for i := range providers {
+		fmt.Printf("Fetching favorite song from %v...\n", providers[i].Name())
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		song, err = providers[i].Fetch(ctx)
+		cancel()
+		if err != nil {
+			log.Print(err)
+			log.Printf("Failed to fetch from %v", providers[i].Name())
+		} else {
+			song.fillDefaults(providers[i].Name())
+			return
+		}
+	}
+
+	err = errors.New("Failed to fetch from all providers...")
+	return
+}
+
+// Get up to count favorites from a list of providers, in the same fallback order as GetFavorite
+//
+// Every provider must be a ListProvider when count is greater than 1 (see CheckCount).
+func GetFavorites(providers []Provider, count int, timeout time.Duration) (songs []Song, err error) {
+	if count <= 1 {
+		var song Song
+		song, err = GetFavorite(providers, timeout)
+		if err != nil {
+			return
+		}
+		return []Song{song}, nil
+	}
+
+	for i := range providers {
+		fmt.Printf("Fetching the %v favorite songs from %v...\n", count, providers[i].Name())
+
+		list_provider, ok := providers[i].(ListProvider)
+		if !ok {
+			log.Printf("The provider %v can't get a list of songs", providers[i].Name())
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		songs, err = list_provider.FetchList(ctx, count)
+		cancel()
+		if err == nil && len(songs) == 0 {
+			err = errors.New("The provider did not return any song.")
+		}
+		if err != nil {
+			log.Print(err)
+			log.Printf("Failed to fetch from %v", providers[i].Name())
+			continue
+		}
+
+		if len(songs) > count {
+			songs = songs[:count]
+		}
+		for j := range songs {
+			songs[j].fillDefaults(providers[i].Name())
+			songs[j].Rank = j + 1
+		}
+		return
+	}
+
+	err = errors.New("Failed to fetch from all providers...")
+	return
+}
+
+// Set the fields that the provider didn't set
+func (song *Song) fillDefaults(provider_type ProviderType) {
+	if song.Provider == "" {
+		song.Provider = provider_type
+	}
+	if song.Entity == "" {
+		song.Entity = EntityTrack
+	}
+}
+
+// Move the provider with that name to the wanted index, used to follow the --fallback order
+func MoveProviderToIndex(providers []Provider, provider_type ProviderType, wanted_index int) (err error) {
+	for i := range providers {
+		if providers[i].Name() == provider_type {
+			if i == wanted_index {
+				return nil
+			} else {
+				providers[wanted_index], providers[i] = providers[i], providers[wanted_index]
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("Couldn't find the provider \"%v\" inside of the passed --fallback (%v)", provider_type, providers)
+}
+
+// Make every provider fetch the entity, returns an error if one of them can't
+func SetEntity(providers []Provider, entity Entity) error {
+	if entity == EntityTrack {
+		return nil
+	}
+	for i := range providers {
+		entity_provider, ok := providers[i].(EntityProvider)
+		if !ok {
+			return fmt.Errorf("The provider %v can only get the favorite track, not the favorite %v.", providers[i].Name(), entity)
+		}
+		if err := entity_provider.SetEntity(entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check that every provider can get count songs, returns an error if one of them can't
+func CheckCount(providers []Provider, count int) error {
+	if count <= 1 {
+		return nil
+	}
+	for i := range providers {
+		if _, ok := providers[i].(ListProvider); !ok {
+			return fmt.Errorf("The provider %v can only get the favorite song, not a list of %v songs.", providers[i].Name(), count)
+		}
+	}
+	return nil
+}
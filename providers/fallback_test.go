@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetEntity(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []Provider
+		entity    Entity
+		err       string
+	}{
+		{"track with any provider", []Provider{&YoutubeProvider{}, &LastFmProvider{Mode: LastFmRecent}}, EntityTrack, ""},
+		{"artist in top mode", []Provider{&LastFmProvider{Mode: LastFmTop}, &ListenbrainzProvider{Mode: ListenbrainzTop}}, EntityArtist, ""},
+		{"provider without entities", []Provider{&LastFmProvider{Mode: LastFmTop}, &YoutubeProvider{}}, EntityArtist, "can only get the favorite track"},
+		{"recent mode", []Provider{&LastFmProvider{Mode: LastFmRecent}}, EntityAlbum, "\"top\" last.fm mode"},
+		{"pinned mode", []Provider{&ListenbrainzProvider{Mode: ListenbrainzPinned}}, EntityArtist, "\"top\" listenbrainz mode"},
+		{"album of the takeout", []Provider{&YoutubeTakeoutProvider{}}, EntityAlbum, "albums"},
+	}
+	for _, test := range tests {
+		err := SetEntity(test.providers, test.entity)
+		if test.err == "" && err != nil {
+			t.Errorf("%v: %v", test.name, err)
+		} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%v: got the error %v, want one with %q", test.name, err, test.err)
+		}
+	}
+
+	lastfm := &LastFmProvider{Mode: LastFmTop}
+	if err := SetEntity([]Provider{lastfm}, EntityAlbum); err != nil || lastfm.Entity != EntityAlbum {
+		t.Errorf("Got %v and the entity %v, want album", err, lastfm.Entity)
+	}
+}
+
+// Provider that answers the songs or the error, fakeListProvider can also answer a list
+type fakeProvider struct {
+	name  ProviderType
+	songs []Song
+	err   error
+}
+
+func (p *fakeProvider) Name() ProviderType                { return p.name }
+func (p *fakeProvider) RegisterFlags(flags *flag.FlagSet) {}
+func (p *fakeProvider) Configured() bool                  { return true }
+func (p *fakeProvider) Validate() error                   { return nil }
+
+func (p *fakeProvider) Fetch(ctx context.Context) (Song, error) {
+	return firstSong(p.songs, p.err)
+}
+
+type fakeListProvider struct {
+	fakeProvider
+}
+
+// Returns a copy of every song whatever the count, like a provider that doesn't know about limits
+func (p *fakeListProvider) FetchList(ctx context.Context, count int) ([]Song, error) {
+	return slices.Clone(p.songs), p.err
+}
+
+func TestGetFavorites(t *testing.T) {
+	songs := []Song{{Title: "One"}, {Title: "Two", Entity: EntityArtist, Provider: LastFm}, {Title: "Three"}}
+	failing := &fakeListProvider{fakeProvider{name: "failing", err: errors.New("failed")}}
+	empty := &fakeListProvider{fakeProvider{name: "empty"}}
+	single := &fakeProvider{name: "single", songs: songs}
+	list := &fakeListProvider{fakeProvider{name: "list", songs: songs}}
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		count     int
+		want      []Song
+	}{
+		{
+			// A single song isn't ranked
+			name: "single song", providers: []Provider{single}, count: 1,
+			want: []Song{{Title: "One", Entity: EntityTrack, Provider: "single"}},
+		},
+		{
+			// The songs are cut off at the count and ranked, the fields set by the provider are kept
+			name: "list", providers: []Provider{list}, count: 2,
+			want: []Song{
+				{Title: "One", Entity: EntityTrack, Provider: "list", Rank: 1},
+				{Title: "Two", Entity: EntityArtist, Provider: LastFm, Rank: 2},
+			},
+		},
+		{
+			name: "fewer songs than the count", providers: []Provider{list}, count: 5,
+			want: []Song{
+				{Title: "One", Entity: EntityTrack, Provider: "list", Rank: 1},
+				{Title: "Two", Entity: EntityArtist, Provider: LastFm, Rank: 2},
+				{Title: "Three", Entity: EntityTrack, Provider: "list", Rank: 3},
+			},
+		},
+		{
+			name: "fallback", providers: []Provider{failing, empty, single, list}, count: 1,
+			want: []Song{{Title: "One", Entity: EntityTrack, Provider: "single"}},
+		},
+		{
+			// The providers that can't list are skipped
+			name: "list fallback", providers: []Provider{failing, empty, single, list}, count: 2,
+			want: []Song{
+				{Title: "One", Entity: EntityTrack, Provider: "list", Rank: 1},
+				{Title: "Two", Entity: EntityArtist, Provider: LastFm, Rank: 2},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := GetFavorites(test.providers, test.count, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+
+	if got, err := GetFavorites([]Provider{failing, empty, single}, 2, time.Second); err == nil {
+		t.Errorf("Got %+v without any provider that answers a list", got)
+	}
+}
+
+func TestCheckCount(t *testing.T) {
+	single := &fakeProvider{name: "single"}
+	list := &fakeListProvider{fakeProvider{name: "list"}}
+
+	tests := []struct {
+		providers []Provider
+		count     int
+		ok        bool
+	}{
+		{[]Provider{single, list}, 1, true},
+		{[]Provider{list}, 10, true},
+		{[]Provider{list, single}, 2, false},
+		{[]Provider{&YoutubeProvider{}}, 3, false},
+		{[]Provider{&LastFmProvider{}, &ListenbrainzProvider{}, &SpotifyProvider{}}, 3, true},
+	}
+	for _, test := range tests {
+		err := CheckCount(test.providers, test.count)
+		if (err == nil) != test.ok {
+			t.Errorf("%v with a count of %v: got %v", test.providers, test.count, err)
+		}
+	}
+}
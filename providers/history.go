@@ -0,0 +1,186 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Periods understood by the providers reading a listening history from a file,
+// they are the same as the last.fm periods
+const (
+	Period7Days    = "7day"
+	Period1Month   = "1month"
+	Period3Months  = "3month"
+	Period6Months  = "6month"
+	Period12Months = "12month"
+	PeriodOverall  = "overall"
+)
+
+// A single listen read from an export
+type historyListen struct {
+	Time time.Time
+	Song Song
+	// Listens with the same key are the same song
+	Key string
+	// How much this listen counts towards the favorite song (milliseconds played, or 1 per play)
+	Weight int64
+	// Whether this listen counts as a play (spotify only counts streams of more than 30 seconds)
+	Played bool
+}
+
+// Key used to group the listens of the same entity, empty if the listen doesn't have that entity
+func (listen historyListen) entityKey(entity Entity) string {
+	switch entity {
+	case EntityArtist:
+		return strings.ToLower(listen.Song.Author())
+	case EntityAlbum:
+		if listen.Song.Album == "" {
+			return ""
+		}
+		return strings.ToLower(listen.Song.Album) + "\x00" + strings.ToLower(listen.Song.Author())
+	}
+	return listen.Key
+}
+
+// Only keep what describes the entity of the song
+func (listen historyListen) entitySong(entity Entity) Song {
+	song := listen.Song
+	song.Entity = entity
+	switch entity {
+	case EntityArtist:
+		return Song{Entity: entity, Artists: song.Artists, Provider: song.Provider}
+	case EntityAlbum:
+		song.Title, song.Link, song.RecordingMbid = "", "", ""
+	}
+	return song
+}
+
+// Check that the period is one of the history periods
+func validateHistoryPeriod(period string) error {
+	switch period {
+	case Period7Days, Period1Month, Period3Months, Period6Months, Period12Months, PeriodOverall:
+		return nil
+	}
+	return fmt.Errorf("Unknown period \"%v\", %v, %v, %v, %v, %v and %v are the valid periods.", period, Period7Days, Period1Month, Period3Months, Period6Months, Period12Months, PeriodOverall)
+}
+
+// Start of the period, counted back from end
+func historyPeriodStart(period string, end time.Time) time.Time {
+	switch period {
+	case Period7Days:
+		return end.AddDate(0, 0, -7)
+	case Period1Month:
+		return end.AddDate(0, -1, 0)
+	case Period3Months:
+		return end.AddDate(0, -3, 0)
+	case Period6Months:
+		return end.AddDate(0, -6, 0)
+	case Period12Months:
+		return end.AddDate(-1, 0, 0)
+	}
+	return time.Time{}
+}
+
+// Expand a comma separated list of files, globs and directories into a sorted list of files
+//
+// Directories are searched for files matching directory_pattern.
+func expandHistoryFiles(files string, directory_pattern string) (expanded []string, err error) {
+	seen := map[string]bool{}
+	for _, pattern := range strings.Split(files, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if info, stat_err := os.Stat(pattern); stat_err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, directory_pattern)
+		}
+
+		var matches []string
+		matches, err = filepath.Glob(pattern)
+		if err != nil {
+			return
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No history file found for \"%v\"", pattern)
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				expanded = append(expanded, match)
+			}
+		}
+	}
+
+	sort.Strings(expanded)
+	return
+}
+
+// Get the count favorite tracks, artists or albums of the listens inside of the period
+//
+// The period ends at the latest listen instead of now, so that the result only depends on the files.
+func topFromHistory(listens []historyListen, period string, entity Entity, count int) (songs []Song, err error) {
+	if len(listens) == 0 {
+		err = errors.New("The history is empty.")
+		return
+	}
+
+	latest := listens[0].Time
+	for i := range listens {
+		if listens[i].Time.After(latest) {
+			latest = listens[i].Time
+		}
+	}
+	start := historyPeriodStart(period, latest)
+
+	type total struct {
+		song   Song
+		weight int64
+		plays  int
+	}
+	totals := map[string]*total{}
+	for i := range listens {
+		key := listens[i].entityKey(entity)
+		if key == "" || listens[i].Time.Before(start) {
+			continue
+		}
+		t, ok := totals[key]
+		if !ok {
+			t = &total{song: listens[i].entitySong(entity)}
+			totals[key] = t
+		}
+		t.weight += listens[i].Weight
+		if listens[i].Played {
+			t.plays++
+		}
+	}
+
+	// Ties are broken with the key so that every run gives the same result
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		err = errors.New("There are no listens inside of that period.")
+		return
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if totals[keys[i]].weight != totals[keys[j]].weight {
+			return totals[keys[i]].weight > totals[keys[j]].weight
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys[:min(count, len(keys))] {
+		song := totals[key].song
+		song.PlayCount = totals[key].plays
+		song.Period = period
+		songs = append(songs, song)
+	}
+	return
+}
@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Listen of the song at that day of 2025, weighted by the milliseconds played
+func testListen(day int, title string, artist string, album string, weight int64) historyListen {
+	return historyListen{
+		Time:   time.Date(2025, time.March, day, 12, 0, 0, 0, time.UTC),
+		Song:   Song{Title: title, Artists: []string{artist}, Album: album, Provider: SpotifyHistory, Link: "https://open.spotify.com/track/" + title},
+		Key:    strings.ToLower(title),
+		Weight: weight,
+		Played: weight >= spotifyStreamThreshold,
+	}
+}
+
+func TestTopFromHistory(t *testing.T) {
+	listens := []historyListen{
+		// Long ago, only counted for the whole history
+		testListen(1, "Old", "Z", "Past", 900_000),
+		testListen(20, "One", "A", "First", 200_000),
+		testListen(21, "One", "A", "First", 200_000),
+		// Skipped, it counts for the time but not as a play
+		testListen(22, "One", "A", "First", 10_000),
+		testListen(23, "Two", "a", "first", 300_000),
+		testListen(24, "Three", "B", "", 350_000),
+		testListen(25, "Four", "B", "Third", 100_000),
+		// Exactly the threshold, it counts as a play
+		testListen(25, "Four", "B", "Third", spotifyStreamThreshold),
+	}
+
+	tests := []struct {
+		name   string
+		period string
+		entity Entity
+		count  int
+		want   []Song
+	}{
+		{
+			name: "tracks of the week", period: Period7Days, entity: EntityTrack, count: 3,
+			want: []Song{
+				{Entity: EntityTrack, Title: "One", Artists: []string{"A"}, Album: "First", Provider: SpotifyHistory, Link: "https://open.spotify.com/track/One", PlayCount: 2, Period: Period7Days},
+				{Entity: EntityTrack, Title: "Three", Artists: []string{"B"}, Provider: SpotifyHistory, Link: "https://open.spotify.com/track/Three", PlayCount: 1, Period: Period7Days},
+				{Entity: EntityTrack, Title: "Two", Artists: []string{"a"}, Album: "first", Provider: SpotifyHistory, Link: "https://open.spotify.com/track/Two", PlayCount: 1, Period: Period7Days},
+			},
+		},
+		{
+			name: "overall track", period: PeriodOverall, entity: EntityTrack, count: 1,
+			want: []Song{
+				{Entity: EntityTrack, Title: "Old", Artists: []string{"Z"}, Album: "Past", Provider: SpotifyHistory, Link: "https://open.spotify.com/track/Old", PlayCount: 1, Period: PeriodOverall},
+			},
+		},
+		// The artists and the albums are grouped without the case
+		{
+			name: "artists", period: Period7Days, entity: EntityArtist, count: 5,
+			want: []Song{
+				{Entity: EntityArtist, Artists: []string{"A"}, Provider: SpotifyHistory, PlayCount: 3, Period: Period7Days},
+				{Entity: EntityArtist, Artists: []string{"B"}, Provider: SpotifyHistory, PlayCount: 3, Period: Period7Days},
+			},
+		},
+		// Listens without an album are ignored
+		{
+			name: "albums", period: Period7Days, entity: EntityAlbum, count: 5,
+			want: []Song{
+				{Entity: EntityAlbum, Artists: []string{"A"}, Album: "First", Provider: SpotifyHistory, PlayCount: 3, Period: Period7Days},
+				{Entity: EntityAlbum, Artists: []string{"B"}, Album: "Third", Provider: SpotifyHistory, PlayCount: 2, Period: Period7Days},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			songs, err := topFromHistory(listens, test.period, test.entity, test.count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(songs, test.want) {
+				t.Errorf("Got %+v, want %+v", songs, test.want)
+			}
+		})
+	}
+}
+
+func TestTopFromHistoryTies(t *testing.T) {
+	// The same weight, the result must not depend on the order of the listens
+	listens := []historyListen{
+		testListen(1, "b", "B", "", 60_000),
+		testListen(2, "c", "C", "", 60_000),
+		testListen(3, "a", "A", "", 60_000),
+	}
+	for range 10 {
+		songs, err := topFromHistory(listens, PeriodOverall, EntityTrack, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if songs[0].Title != "a" {
+			t.Fatalf("Got %v, want a", songs[0].Title)
+		}
+		listens = append(listens[1:], listens[0])
+	}
+}
+
+func TestTopFromHistoryErrors(t *testing.T) {
+	if _, err := topFromHistory(nil, PeriodOverall, EntityTrack, 1); err == nil {
+		t.Error("No error for an empty history")
+	}
+	// No listen has an album
+	listens := []historyListen{testListen(1, "a", "A", "", 60_000)}
+	if _, err := topFromHistory(listens, PeriodOverall, EntityAlbum, 1); err == nil {
+		t.Error("No error without any album")
+	}
+}
+
+func TestHistoryPeriodStart(t *testing.T) {
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		start  time.Time
+	}{
+		{Period7Days, time.Date(2025, time.March, 24, 0, 0, 0, 0, time.UTC)},
+		// Normalized like time.AddDate, the 31st of February is the 3rd of March
+		{Period1Month, time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)},
+		{Period12Months, time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)},
+		{PeriodOverall, time.Time{}},
+	}
+	for _, test := range tests {
+		if start := historyPeriodStart(test.period, end); !start.Equal(test.start) {
+			t.Errorf("%v: got %v, want %v", test.period, start, test.start)
+		}
+	}
+	if err := validateHistoryPeriod("forever"); err == nil {
+		t.Error("No error for an unknown period")
+	}
+}
@@ -0,0 +1,152 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const Jellyfin ProviderType = "jellyfin"
+
+// Servers supported by the jellyfin provider, the API is the same but the web client links are not
+const (
+	JellyfinServer = "jellyfin"
+	EmbyServer     = "emby"
+)
+
+func init() {
+	Register(Jellyfin, func() Provider { return &JellyfinProvider{} })
+}
+
+// Get the most played track of a user on a jellyfin or emby server
+type JellyfinProvider struct {
+	Url    string
+	ApiKey string
+	UserId string
+	// JellyfinServer or EmbyServer
+	Server string
+}
+
+func (p *JellyfinProvider) Name() ProviderType {
+	return Jellyfin
+}
+
+func (p *JellyfinProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.Url, "jellyfinUrl", "", "Base URL of the jellyfin or emby server where we get the most played song from.")
+	flags.StringVar(&p.ApiKey, "jellyfinApiKey", "", "API key of the jellyfin or emby server, prefer the FAVORITE_MUSIC_BADGE_JELLYFIN_API_KEY environment variable over this flag.")
+	flags.StringVar(&p.UserId, "jellyfinUserId", "", "ID of the jellyfin or emby user (not the username).")
+	flags.StringVar(&p.Server, "jellyfinServer", JellyfinServer, "\"jellyfin\" or \"emby\", used to link to the right web client.")
+}
+
+func (p *JellyfinProvider) Configured() bool {
+	return p.Url != ""
+}
+
+func (p *JellyfinProvider) Validate() error {
+	if p.ApiKey == "" || p.UserId == "" {
+		return errors.New("If the jellyfinUrl flag is given, the jellyfinApiKey and jellyfinUserId flags must also be given.")
+	}
+	if p.Server != JellyfinServer && p.Server != EmbyServer {
+		return fmt.Errorf("Unknown server \"%v\", \"%v\" and \"%v\" are the valid servers.", p.Server, JellyfinServer, EmbyServer)
+	}
+	return nil
+}
+
+func (p *JellyfinProvider) Fetch(ctx context.Context) (song Song, err error) {
+	return firstSong(p.FetchList(ctx, 1))
+}
+
+func (p *JellyfinProvider) FetchList(ctx context.Context, count int) (songs []Song, err error) {
+	return GetMostPlayedFromJellyfin(ctx, p.Url, p.ApiKey, p.UserId, p.Server, count)
+}
+
+type JellyfinItems struct {
+	Items []JellyfinItem `json:"Items"`
+}
+
+type JellyfinItem struct {
+	Id          string            `json:"Id"`
+	Name        string            `json:"Name"`
+	Artists     []string          `json:"Artists"`
+	AlbumArtist string            `json:"AlbumArtist"`
+	Album       string            `json:"Album"`
+	AlbumId     string            `json:"AlbumId"`
+	UserData    JellyfinUserData  `json:"UserData"`
+	ProviderIds map[string]string `json:"ProviderIds"`
+	ImageTags   map[string]string `json:"ImageTags"`
+}
+
+type JellyfinUserData struct {
+	PlayCount int `json:"PlayCount"`
+}
+
+// Get the audio items with the highest play count of that user
+//
+// API documentation: https://api.jellyfin.org/#tag/Items/operation/GetItems
+func GetMostPlayedFromJellyfin(ctx context.Context, server_url string, api_key string, user_id string, server string, count int) (songs []Song, err error) {
+	server_url = strings.TrimSuffix(server_url, "/")
+	parameters := url.Values{
+		"SortBy":           {"PlayCount"},
+		"SortOrder":        {"Descending"},
+		"IncludeItemTypes": {"Audio"},
+		"Filters":          {"IsPlayed"},
+		"Recursive":        {"true"},
+		"Limit":            {fmt.Sprint(count)},
+		"Fields":           {"ProviderIds"},
+	}
+	request := fmt.Sprintf("%v/Users/%v/Items?%v", server_url, url.PathEscape(user_id), parameters.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, request, nil)
+	if err != nil {
+		return
+	}
+	// Understood by both jellyfin and emby
+	req.Header.Set("X-Emby-Token", api_key)
+
+	var items JellyfinItems
+	err = doRequestAndParseJSON(req, "https://api.jellyfin.org/#tag/Items/operation/GetItems", &items)
+	if err != nil {
+		return
+	}
+	if len(items.Items) == 0 {
+		err = errors.New("No played tracks were found on the jellyfin server for that user.")
+		return
+	}
+
+	for _, item := range items.Items {
+		songs = append(songs, item.song(server_url, server))
+	}
+	return
+}
+
+// Convert a jellyfin audio item to a song
+func (item JellyfinItem) song(server_url string, server string) Song {
+	song := Song{
+		Title:         item.Name,
+		Artists:       item.Artists,
+		Album:         item.Album,
+		PlayCount:     item.UserData.PlayCount,
+		Period:        "all_time",
+		Provider:      Jellyfin,
+		RecordingMbid: item.ProviderIds["MusicBrainzTrack"],
+	}
+	if len(song.Artists) == 0 && item.AlbumArtist != "" {
+		song.Artists = []string{item.AlbumArtist}
+	}
+
+	if server == EmbyServer {
+		song.Link = fmt.Sprintf("%v/web/index.html#!/item?id=%v", server_url, url.QueryEscape(item.Id))
+	} else {
+		song.Link = fmt.Sprintf("%v/web/#/details?id=%v", server_url, url.QueryEscape(item.Id))
+	}
+
+	if _, ok := item.ImageTags["Primary"]; ok {
+		song.CoverArtUrl = fmt.Sprintf("%v/Items/%v/Images/Primary", server_url, url.PathEscape(item.Id))
+	} else if item.AlbumId != "" {
+		song.CoverArtUrl = fmt.Sprintf("%v/Items/%v/Images/Primary", server_url, url.PathEscape(item.AlbumId))
+	}
+	return song
+}
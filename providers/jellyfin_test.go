@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const TEST_JELLYFIN_ITEMS = `{"Items": [
+	{"Id": "i1", "Name": "One", "Artists": ["A", "B"], "Album": "First", "AlbumId": "a1", "UserData": {"PlayCount": 9}, "ProviderIds": {"MusicBrainzTrack": "mbid"}, "ImageTags": {"Primary": "tag"}},
+	{"Id": "i2", "Name": "Two", "Artists": [], "AlbumArtist": "C", "Album": "Second", "AlbumId": "a2", "UserData": {"PlayCount": 4}}
+], "TotalRecordCount": 2}`
+
+// Fake jellyfin server for the user u1, items is the answer of the items
+func fakeJellyfin(t *testing.T, items string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Emby-Token") != "key" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/jellyfin/Users/u1/Items" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("SortBy") != "PlayCount" || query.Get("SortOrder") != "Descending" || query.Get("IncludeItemTypes") != "Audio" || query.Get("Limit") != "2" {
+			t.Errorf("Unexpected query %v", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, items)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJellyfinFetchList(t *testing.T) {
+	server := fakeJellyfin(t, TEST_JELLYFIN_ITEMS)
+	base := server.URL + "/jellyfin"
+
+	tests := []struct {
+		server string
+		links  []string
+	}{
+		{JellyfinServer, []string{base + "/web/#/details?id=i1", base + "/web/#/details?id=i2"}},
+		{EmbyServer, []string{base + "/web/index.html#!/item?id=i1", base + "/web/index.html#!/item?id=i2"}},
+	}
+	for _, test := range tests {
+		provider := JellyfinProvider{Url: base + "/", ApiKey: "key", UserId: "u1", Server: test.server}
+		songs, err := provider.FetchList(context.Background(), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Song{
+			{Title: "One", Artists: []string{"A", "B"}, Album: "First", PlayCount: 9, Period: "all_time", Provider: Jellyfin, RecordingMbid: "mbid", Link: test.links[0], CoverArtUrl: base + "/Items/i1/Images/Primary"},
+			// Without artists the album artist is used, and without image the one of the album
+			{Title: "Two", Artists: []string{"C"}, Album: "Second", PlayCount: 4, Period: "all_time", Provider: Jellyfin, Link: test.links[1], CoverArtUrl: base + "/Items/a2/Images/Primary"},
+		}
+		if !reflect.DeepEqual(songs, want) {
+			t.Errorf("%v: got %+v, want %+v", test.server, songs, want)
+		}
+	}
+}
+
+func TestJellyfinErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   string
+		api_key string
+		err     string
+	}{
+		{"wrong api key", TEST_JELLYFIN_ITEMS, "wrong", "401"},
+		{"nothing played", `{"Items": []}`, "key", "No played tracks"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := fakeJellyfin(t, test.items)
+			provider := JellyfinProvider{Url: server.URL + "/jellyfin", ApiKey: test.api_key, UserId: "u1", Server: JellyfinServer}
+			_, err := provider.FetchList(context.Background(), 2)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Got the error %v, want one with %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const LastFm ProviderType = "lastfm"
+
+// Modes of the last.fm provider
+const (
+	// Top track over a period
+	LastFmTop = "top"
+	// Latest loved track
+	LastFmLoved = "loved"
+	// Track currently playing, or the last scrobble
+	LastFmRecent = "recent"
+)
+
+func init() {
+	Register(LastFm, func() Provider { return &LastFmProvider{} })
+}
+
+// Get the top, latest loved or currently playing song of a last.fm user
+type LastFmProvider struct {
+	Username string
+	APIKey   string
+	Mode     string
+	// Only used by the top mode
+	Period string
+	Entity Entity
+	ApiUrl string
+}
+
+func (p *LastFmProvider) Name() ProviderType {
+	return LastFm
+}
+
+func (p *LastFmProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.Username, "lastFmUsername", "", "Last.fm username where we get the top song from.")
+	flags.StringVar(&p.Mode, "lastFmMode", LastFmTop, "\"top\" takes the top song over -lastFmPeriod, \"loved\" the latest loved song, \"recent\" the song currently playing (or the last scrobble).")
+	flags.StringVar(&p.Period, "lastFmPeriod", "7day", "Last.fm period over which to retrieve top tracks for.")
+	flags.StringVar(&p.ApiUrl, "lastFmApiUrl", "http://ws.audioscrobbler.com/2.0/", "Base URL of the last.fm API.")
+	flags.StringVar(&p.APIKey, "lastFmAPIKey", "", "Last.fm API key, the LAST_FM_API_KEY environment variable can also be used.")
+}
+
+func (p *LastFmProvider) Configured() bool {
+	return p.Username != ""
+}
+
+func (p *LastFmProvider) Validate() error {
+	if p.APIKey == "" {
+		return errors.New("If the lastFmUsername flag is given, the LAST_FM_API_KEY environment variable (or lastFmAPIKey) must be given.")
+	}
+	if p.Mode != LastFmTop && p.Mode != LastFmLoved && p.Mode != LastFmRecent {
+		return fmt.Errorf("Unknown last.fm mode \"%v\", \"%v\", \"%v\" and \"%v\" are the valid modes.", p.Mode, LastFmTop, LastFmLoved, LastFmRecent)
+	}
+	return nil
+}
+
+func (p *LastFmProvider) Fetch(ctx context.Context) (song Song, err error) {
+	return firstSong(p.FetchList(ctx, 1))
+}
+
+func (p *LastFmProvider) FetchList(ctx context.Context, count int) (songs []Song, err error) {
+	switch p.Mode {
+	case LastFmLoved:
+		return GetLovedSongsFromLastFm(ctx, p.ApiUrl, p.Username, p.APIKey, count)
+	case LastFmRecent:
+		return GetRecentSongsFromLastFm(ctx, p.ApiUrl, p.Username, p.APIKey, count)
+	}
+
+	switch p.Entity {
+	case EntityArtist:
+		return GetTopArtistsFromLastFm(ctx, p.ApiUrl, p.Username, p.Period, p.APIKey, count)
+	case EntityAlbum:
+		return GetTopAlbumsFromLastFm(ctx, p.ApiUrl, p.Username, p.Period, p.APIKey, count)
+	}
+	return GetTopSongsFromLastFm(ctx, p.ApiUrl, p.Username, p.Period, p.APIKey, count)
+}
+
+func (p *LastFmProvider) SetEntity(entity Entity) error {
+	if entity != EntityTrack && p.Mode != LastFmTop {
+		return fmt.Errorf("The favorite %v can only be used with the \"%v\" last.fm mode.", entity, LastFmTop)
+	}
+	p.Entity = entity
+	return nil
+}
+
+// Last.fm artist when we are parsing
+type Artist struct {
+	Name string `json:"name"`
+}
+
+// Last.fm image, Size is small, medium, large or extralarge
+type Image struct {
+	Url  string `json:"#text"`
+	Size string `json:"size"`
+}
+
+// Last.fm track information parsed as json
+type Track struct {
+	Name      string  `json:"name"`
+	Url       string  `json:"url"`
+	Mbid      string  `json:"mbid"`
+	PlayCount string  `json:"playcount"`
+	Artist    Artist  `json:"artist"`
+	Image     []Image `json:"image"`
+}
+
+// Last fm toptrack information
+type TopTracks struct {
+	Track []Track `json:"track"`
+}
+
+type LastFMTopTracks struct {
+	TopTracks TopTracks `json:"toptracks"`
+}
+
+// Last.fm top artist, or top album (then Artist is set)
+type TopEntity struct {
+	Name      string  `json:"name"`
+	Url       string  `json:"url"`
+	Mbid      string  `json:"mbid"`
+	PlayCount string  `json:"playcount"`
+	Artist    Artist  `json:"artist"`
+	Image     []Image `json:"image"`
+}
+
+type TopArtists struct {
+	Artist []TopEntity `json:"artist"`
+}
+
+type LastFMTopArtists struct {
+	TopArtists TopArtists `json:"topartists"`
+}
+
+type TopAlbums struct {
+	Album []TopEntity `json:"album"`
+}
+
+type LastFMTopAlbums struct {
+	TopAlbums TopAlbums `json:"topalbums"`
+}
+
+type LastFMLovedTracks struct {
+	LovedTracks TopTracks `json:"lovedtracks"`
+}
+
+// Recent tracks use #text for the artist and the album instead of name
+type RecentTrack struct {
+	Name   string          `json:"name"`
+	Url    string          `json:"url"`
+	Mbid   string          `json:"mbid"`
+	Artist RecentText      `json:"artist"`
+	Album  RecentText      `json:"album"`
+	Image  []Image         `json:"image"`
+	Attr   RecentTrackAttr `json:"@attr"`
+}
+
+type RecentText struct {
+	Text string `json:"#text"`
+}
+
+type RecentTrackAttr struct {
+	NowPlaying string `json:"nowplaying"`
+}
+
+type RecentTracks struct {
+	Track []RecentTrack `json:"track"`
+}
+
+type LastFMRecentTracks struct {
+	RecentTracks RecentTracks `json:"recenttracks"`
+}
+
+// Get the top songs from the lastfm API, would work inside of cicd
+//
+// API documentation: https://www.last.fm/api/show/user.getTopTracks
+func GetTopSongsFromLastFm(ctx context.Context, api_url string, user string, period string, api_key string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/?method=user.gettoptracks&user=%v&period=%v&api_key=%v&limit=%v&format=json", strings.TrimSuffix(api_url, "/"), user, period, api_key, count)
+
+	var lastFMTopTracks LastFMTopTracks
+	err = sendRequestAndParseJSON(ctx, request, "https://www.last.fm/api/show/user.getTopTracks", &lastFMTopTracks)
+	if err != nil {
+		return
+	}
+
+	if len(lastFMTopTracks.TopTracks.Track) == 0 {
+		err = errors.New("No top tracks were found on last.fm for that period.")
+		return
+	}
+
+	for _, track := range lastFMTopTracks.TopTracks.Track {
+		play_count, _ := strconv.Atoi(track.PlayCount)
+		songs = append(songs, Song{
+			Title:         track.Name,
+			Artists:       []string{track.Artist.Name},
+			PlayCount:     play_count,
+			Period:        period,
+			Provider:      LastFm,
+			Link:          track.Url,
+			RecordingMbid: track.Mbid,
+			CoverArtUrl:   largestLastFmImage(track.Image),
+		})
+	}
+	return
+}
+
+// Get the url of the biggest image, last.fm orders them from the smallest to the biggest
+func largestLastFmImage(images []Image) string {
+	for i := len(images) - 1; i >= 0; i-- {
+		if images[i].Url != "" {
+			return images[i].Url
+		}
+	}
+	return ""
+}
+
+// Get the latest loved songs from the lastfm API, the latest one first
+//
+// API documentation: https://www.last.fm/api/show/user.getLovedTracks
+func GetLovedSongsFromLastFm(ctx context.Context, api_url string, user string, api_key string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/?method=user.getlovedtracks&user=%v&api_key=%v&limit=%v&format=json", strings.TrimSuffix(api_url, "/"), user, api_key, count)
+
+	var lastFMLovedTracks LastFMLovedTracks
+	err = sendRequestAndParseJSON(ctx, request, "https://www.last.fm/api/show/user.getLovedTracks", &lastFMLovedTracks)
+	if err != nil {
+		return
+	}
+
+	if len(lastFMLovedTracks.LovedTracks.Track) == 0 {
+		err = errors.New("That last.fm user has never loved a track.")
+		return
+	}
+
+	for _, track := range lastFMLovedTracks.LovedTracks.Track {
+		songs = append(songs, Song{
+			Title:         track.Name,
+			Artists:       []string{track.Artist.Name},
+			Provider:      LastFm,
+			Link:          track.Url,
+			RecordingMbid: track.Mbid,
+			CoverArtUrl:   largestLastFmImage(track.Image),
+		})
+	}
+	return
+}
+
+// Get the song currently playing followed by the last scrobbles from the lastfm API
+//
+// API documentation: https://www.last.fm/api/show/user.getRecentTracks
+func GetRecentSongsFromLastFm(ctx context.Context, api_url string, user string, api_key string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/?method=user.getrecenttracks&user=%v&api_key=%v&limit=%v&format=json", strings.TrimSuffix(api_url, "/"), user, api_key, count)
+
+	var lastFMRecentTracks LastFMRecentTracks
+	err = sendRequestAndParseJSON(ctx, request, "https://www.last.fm/api/show/user.getRecentTracks", &lastFMRecentTracks)
+	if err != nil {
+		return
+	}
+
+	if len(lastFMRecentTracks.RecentTracks.Track) == 0 {
+		err = errors.New("That last.fm user has never scrobbled a track.")
+		return
+	}
+
+	for _, track := range lastFMRecentTracks.RecentTracks.Track {
+		songs = append(songs, Song{
+			Title:         track.Name,
+			Artists:       []string{track.Artist.Text},
+			Album:         track.Album.Text,
+			Provider:      LastFm,
+			Link:          track.Url,
+			RecordingMbid: track.Mbid,
+			CoverArtUrl:   largestLastFmImage(track.Image),
+			NowPlaying:    track.Attr.NowPlaying == "true",
+			Recent:        true,
+		})
+	}
+
+	// The track currently playing is always the first one and is not counted inside of the limit
+	if len(songs) > count {
+		songs = songs[:count]
+	}
+	return
+}
+
+// Get the top artists from the lastfm API
+//
+// API documentation: https://www.last.fm/api/show/user.getTopArtists
+func GetTopArtistsFromLastFm(ctx context.Context, api_url string, user string, period string, api_key string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/?method=user.gettopartists&user=%v&period=%v&api_key=%v&limit=%v&format=json", strings.TrimSuffix(api_url, "/"), user, period, api_key, count)
+
+	var lastFMTopArtists LastFMTopArtists
+	err = sendRequestAndParseJSON(ctx, request, "https://www.last.fm/api/show/user.getTopArtists", &lastFMTopArtists)
+	if err != nil {
+		return
+	}
+
+	if len(lastFMTopArtists.TopArtists.Artist) == 0 {
+		err = errors.New("No top artists were found on last.fm for that period.")
+		return
+	}
+
+	for _, artist := range lastFMTopArtists.TopArtists.Artist {
+		play_count, _ := strconv.Atoi(artist.PlayCount)
+		songs = append(songs, Song{
+			Entity:    EntityArtist,
+			Artists:   []string{artist.Name},
+			PlayCount: play_count,
+			Period:    period,
+			Provider:  LastFm,
+			Link:      artist.Url,
+		})
+	}
+	return
+}
+
+// Get the top albums from the lastfm API
+//
+// API documentation: https://www.last.fm/api/show/user.getTopAlbums
+func GetTopAlbumsFromLastFm(ctx context.Context, api_url string, user string, period string, api_key string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/?method=user.gettopalbums&user=%v&period=%v&api_key=%v&limit=%v&format=json", strings.TrimSuffix(api_url, "/"), user, period, api_key, count)
+
+	var lastFMTopAlbums LastFMTopAlbums
+	err = sendRequestAndParseJSON(ctx, request, "https://www.last.fm/api/show/user.getTopAlbums", &lastFMTopAlbums)
+	if err != nil {
+		return
+	}
+
+	if len(lastFMTopAlbums.TopAlbums.Album) == 0 {
+		err = errors.New("No top albums were found on last.fm for that period.")
+		return
+	}
+
+	for _, album := range lastFMTopAlbums.TopAlbums.Album {
+		play_count, _ := strconv.Atoi(album.PlayCount)
+		songs = append(songs, Song{
+			Entity:      EntityAlbum,
+			Artists:     []string{album.Artist.Name},
+			Album:       album.Name,
+			PlayCount:   play_count,
+			Period:      period,
+			Provider:    LastFm,
+			Link:        album.Url,
+			CoverArtUrl: largestLastFmImage(album.Image),
+		})
+	}
+	return
+}
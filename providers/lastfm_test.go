@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const TEST_LASTFM_LOVED = `{"lovedtracks": {"track": [
+	{"name": "One", "url": "https://www.last.fm/music/A/_/One", "mbid": "mbid1", "artist": {"name": "A"}, "image": [{"#text": "https://lastfm/small", "size": "small"}, {"#text": "https://lastfm/large", "size": "large"}]},
+	{"name": "Two", "url": "https://www.last.fm/music/B/_/Two", "mbid": "", "artist": {"name": "B"}, "image": [{"#text": "", "size": "small"}]}
+]}}`
+
+// The track currently playing comes first and isn't counted inside of the limit of 2
+const TEST_LASTFM_RECENT = `{"recenttracks": {"track": [
+	{"name": "Now", "url": "https://www.last.fm/music/A/_/Now", "artist": {"#text": "A"}, "album": {"#text": "First"}, "image": [], "@attr": {"nowplaying": "true"}},
+	{"name": "Before", "url": "https://www.last.fm/music/B/_/Before", "artist": {"#text": "B"}, "album": {"#text": ""}, "image": [{"#text": "https://lastfm/before", "size": "small"}]},
+	{"name": "Long ago", "url": "https://www.last.fm/music/C/_/Long+ago", "artist": {"#text": "C"}, "album": {"#text": "Third"}, "image": []}
+]}}`
+
+// Fake last.fm API for the user "user" and the key "key", the answers are indexed by the method
+func fakeLastFm(t *testing.T, answers map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/2.0/" || query.Get("format") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		if query.Get("api_key") != "key" {
+			http.Error(w, `{"error": 10, "message": "Invalid API key"}`, http.StatusForbidden)
+			return
+		}
+		if query.Get("user") != "user" || query.Get("limit") != "2" {
+			t.Errorf("Unexpected query %v", r.URL.RawQuery)
+		}
+		answer, ok := answers[query.Get("method")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, answer)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLastFmFetchList(t *testing.T) {
+	server := fakeLastFm(t, map[string]string{
+		"user.getlovedtracks":  TEST_LASTFM_LOVED,
+		"user.getrecenttracks": TEST_LASTFM_RECENT,
+	})
+
+	tests := []struct {
+		mode string
+		want []Song
+	}{
+		{
+			mode: LastFmLoved,
+			want: []Song{
+				{Title: "One", Artists: []string{"A"}, Provider: LastFm, Link: "https://www.last.fm/music/A/_/One", RecordingMbid: "mbid1", CoverArtUrl: "https://lastfm/large"},
+				{Title: "Two", Artists: []string{"B"}, Provider: LastFm, Link: "https://www.last.fm/music/B/_/Two"},
+			},
+		},
+		{
+			// The third track is cut off
+			mode: LastFmRecent,
+			want: []Song{
+				{Title: "Now", Artists: []string{"A"}, Album: "First", Provider: LastFm, Link: "https://www.last.fm/music/A/_/Now", Recent: true, NowPlaying: true},
+				{Title: "Before", Artists: []string{"B"}, Provider: LastFm, Link: "https://www.last.fm/music/B/_/Before", CoverArtUrl: "https://lastfm/before", Recent: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			provider := LastFmProvider{Username: "user", APIKey: "key", Mode: test.mode, ApiUrl: server.URL + "/2.0"}
+			songs, err := provider.FetchList(context.Background(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(songs, test.want) {
+				t.Errorf("Got %+v, want %+v", songs, test.want)
+			}
+		})
+	}
+}
+
+func TestLastFmErrors(t *testing.T) {
+	server := fakeLastFm(t, map[string]string{
+		"user.getlovedtracks":  `{"lovedtracks": {"track": []}}`,
+		"user.getrecenttracks": `{"recenttracks": {"track": []}}`,
+	})
+
+	tests := []struct {
+		name    string
+		mode    string
+		api_key string
+	}{
+		{"never loved", LastFmLoved, "key"},
+		{"never scrobbled", LastFmRecent, "key"},
+		{"wrong api key", LastFmLoved, "wrong"},
+	}
+	for _, test := range tests {
+		provider := LastFmProvider{Username: "user", APIKey: test.api_key, Mode: test.mode, ApiUrl: server.URL + "/2.0/"}
+		if songs, err := provider.FetchList(context.Background(), 2); err == nil {
+			t.Errorf("%v: got %+v without error", test.name, songs)
+		}
+	}
+}
+
+const TEST_LASTFM_TOP_ARTISTS = `{"topartists": {"artist": [
+	{"name": "A", "url": "https://www.last.fm/music/A", "playcount": "30"},
+	{"name": "B", "url": "https://www.last.fm/music/B", "playcount": "10"}
+]}}`
+
+const TEST_LASTFM_TOP_ALBUMS = `{"topalbums": {"album": [
+	{"name": "First", "url": "https://www.last.fm/music/A/First", "playcount": "20", "artist": {"name": "A"}, "image": [{"#text": "https://lastfm/first", "size": "small"}]}
+]}}`
+
+func TestLastFmEntities(t *testing.T) {
+	server := fakeLastFm(t, map[string]string{
+		"user.gettopartists": TEST_LASTFM_TOP_ARTISTS,
+		"user.gettopalbums":  TEST_LASTFM_TOP_ALBUMS,
+	})
+
+	tests := []struct {
+		entity Entity
+		want   []Song
+	}{
+		{
+			entity: EntityArtist,
+			want: []Song{
+				{Entity: EntityArtist, Artists: []string{"A"}, PlayCount: 30, Period: "1month", Provider: LastFm, Link: "https://www.last.fm/music/A"},
+				{Entity: EntityArtist, Artists: []string{"B"}, PlayCount: 10, Period: "1month", Provider: LastFm, Link: "https://www.last.fm/music/B"},
+			},
+		},
+		{
+			entity: EntityAlbum,
+			want: []Song{
+				{Entity: EntityAlbum, Artists: []string{"A"}, Album: "First", PlayCount: 20, Period: "1month", Provider: LastFm, Link: "https://www.last.fm/music/A/First", CoverArtUrl: "https://lastfm/first"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.entity), func(t *testing.T) {
+			provider := LastFmProvider{Username: "user", APIKey: "key", Mode: LastFmTop, Period: "1month", ApiUrl: server.URL + "/2.0/"}
+			if err := provider.SetEntity(test.entity); err != nil {
+				t.Fatal(err)
+			}
+			songs, err := provider.FetchList(context.Background(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(songs, test.want) {
+				t.Errorf("Got %+v, want %+v", songs, test.want)
+			}
+		})
+	}
+
+	// Only the top mode knows about the artists and albums
+	for _, mode := range []string{LastFmLoved, LastFmRecent} {
+		provider := LastFmProvider{Mode: mode}
+		if err := provider.SetEntity(EntityArtist); err == nil {
+			t.Errorf("%v: no error for the favorite artist", mode)
+		}
+		if err := provider.SetEntity(EntityTrack); err != nil {
+			t.Errorf("%v: %v", mode, err)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const Listenbrainz ProviderType = "listenbrainz"
+
+// Modes of the listenbrainz provider
+const (
+	// Latest pinned recording, even if it expired
+	ListenbrainzPinned = "pinned"
+	// Most listened recording over a range
+	ListenbrainzTop = "top"
+	// Latest loved recording
+	ListenbrainzLoved = "loved"
+)
+
+// Ranges of the listenbrainz statistics
+var listenbrainzRanges = []string{"this_week", "this_month", "this_year", "week", "month", "quarter", "year", "half_yearly", "all_time"}
+
+func init() {
+	Register(Listenbrainz, func() Provider { return &ListenbrainzProvider{} })
+}
+
+// Get the latest pinned, the top or the latest loved recording of a listenbrainz user
+type ListenbrainzProvider struct {
+	Username string
+	Mode     string
+	// Only used by the top mode
+	Range  string
+	Entity Entity
+	ApiUrl string
+}
+
+func (p *ListenbrainzProvider) Name() ProviderType {
+	return Listenbrainz
+}
+
+func (p *ListenbrainzProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.Username, "listenbrainzUsername", "", "Listenbrainz username where we get the favorite song from.")
+	flags.StringVar(&p.Mode, "listenbrainzMode", ListenbrainzPinned, "\"pinned\" takes the latest pinned song, \"top\" the most listened song over -listenbrainzRange, \"loved\" the latest loved song.")
+	flags.StringVar(&p.ApiUrl, "listenbrainzApiUrl", "https://api.listenbrainz.org/1", "Base URL of the listenbrainz API.")
+	flags.StringVar(&p.Range, "listenbrainzRange", "all_time", "Range of the listenbrainz statistics used by the top mode ("+strings.Join(listenbrainzRanges, ", ")+").")
+}
+
+func (p *ListenbrainzProvider) Configured() bool {
+	return p.Username != ""
+}
+
+func (p *ListenbrainzProvider) Validate() error {
+	switch p.Mode {
+	case ListenbrainzPinned, ListenbrainzLoved:
+	case ListenbrainzTop:
+		if !slices.Contains(listenbrainzRanges, p.Range) {
+			return fmt.Errorf("Unknown listenbrainz range \"%v\", %v are the valid ranges.", p.Range, strings.Join(listenbrainzRanges, ", "))
+		}
+	default:
+		return fmt.Errorf("Unknown listenbrainz mode \"%v\", \"%v\", \"%v\" and \"%v\" are the valid modes.", p.Mode, ListenbrainzPinned, ListenbrainzTop, ListenbrainzLoved)
+	}
+	return nil
+}
+
+func (p *ListenbrainzProvider) Fetch(ctx context.Context) (song Song, err error) {
+	return firstSong(p.FetchList(ctx, 1))
+}
+
+func (p *ListenbrainzProvider) FetchList(ctx context.Context, count int) (songs []Song, err error) {
+	switch p.Mode {
+	case ListenbrainzTop:
+		switch p.Entity {
+		case EntityArtist:
+			return GetListenbrainzTopArtists(ctx, p.ApiUrl, p.Username, p.Range, count)
+		case EntityAlbum:
+			return GetListenbrainzTopReleases(ctx, p.ApiUrl, p.Username, p.Range, count)
+		}
+		return GetListenbrainzTopRecordings(ctx, p.ApiUrl, p.Username, p.Range, count)
+	case ListenbrainzLoved:
+		return GetListenbrainzLovedRecordings(ctx, p.ApiUrl, p.Username, count)
+	}
+	return GetListenbrainzPinnedRecordings(ctx, p.ApiUrl, p.Username, count)
+}
+
+func (p *ListenbrainzProvider) SetEntity(entity Entity) error {
+	if entity != EntityTrack && p.Mode != ListenbrainzTop {
+		return fmt.Errorf("The favorite %v can only be used with the \"%v\" listenbrainz mode.", entity, ListenbrainzTop)
+	}
+	p.Entity = entity
+	return nil
+}
+
+type ListenbrainzPinnedRecordings struct {
+	PinnedRecordings []PinnedRecording `json:"pinned_recordings"`
+}
+
+type PinnedRecording struct {
+	Created       int           `json:"created"`
+	RecordingMsid string        `json:"recording_msid"`
+	TrackMetadata TrackMetadata `json:"track_metadata"`
+}
+
+type ListenbrainzRecordingStats struct {
+	Payload RecordingStatsPayload `json:"payload"`
+}
+
+type RecordingStatsPayload struct {
+	Recordings []StatsRecording `json:"recordings"`
+}
+
+type StatsRecording struct {
+	ArtistName     string `json:"artist_name"`
+	TrackName      string `json:"track_name"`
+	ReleaseName    string `json:"release_name"`
+	RecordingMbid  string `json:"recording_mbid"`
+	ReleaseMbid    string `json:"release_mbid"`
+	CaaReleaseMbid string `json:"caa_release_mbid"`
+	CaaId          int64  `json:"caa_id"`
+	ListenCount    int    `json:"listen_count"`
+}
+
+type ListenbrainzArtistStats struct {
+	Payload ArtistStatsPayload `json:"payload"`
+}
+
+type ArtistStatsPayload struct {
+	Artists []StatsArtist `json:"artists"`
+}
+
+type StatsArtist struct {
+	ArtistName  string `json:"artist_name"`
+	ArtistMbid  string `json:"artist_mbid"`
+	ListenCount int    `json:"listen_count"`
+}
+
+type ListenbrainzReleaseStats struct {
+	Payload ReleaseStatsPayload `json:"payload"`
+}
+
+type ReleaseStatsPayload struct {
+	Releases []StatsRecording `json:"releases"`
+}
+
+type ListenbrainzFeedback struct {
+	Feedback []Feedback `json:"feedback"`
+}
+
+type Feedback struct {
+	Created       int           `json:"created"`
+	RecordingMsid string        `json:"recording_msid"`
+	RecordingMbid string        `json:"recording_mbid"`
+	TrackMetadata TrackMetadata `json:"track_metadata"`
+}
+
+type ListenbrainzListens struct {
+	Payload Payload `json:"payload"`
+}
+
+type Payload struct {
+	Listens []Listen `json:"listens"`
+}
+
+type Listen struct {
+	RecordingMsid string        `json:"recording_msid"`
+	TrackMetadata TrackMetadata `json:"track_metadata"`
+}
+
+type TrackMetadata struct {
+	AdditionalInfo AdditionalInfo `json:"additional_info"`
+	MbidMapping    MbidMapping    `json:"mbid_mapping"`
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	ReleaseName    string         `json:"release_name"`
+}
+
+type AdditionalInfo struct {
+	OriginUrl string `json:"origin_url"`
+}
+
+type MbidMapping struct {
+	RecordingMbid  string `json:"recording_mbid"`
+	ReleaseMbid    string `json:"release_mbid"`
+	CaaReleaseMbid string `json:"caa_release_mbid"`
+	CaaId          int64  `json:"caa_id"`
+}
+
+// Create a song from the listenbrainz metadata, without a link
+func (metadata TrackMetadata) song() Song {
+	song := Song{
+		Title:         metadata.TrackName,
+		Artists:       []string{metadata.ArtistName},
+		Album:         metadata.ReleaseName,
+		Provider:      Listenbrainz,
+		RecordingMbid: metadata.MbidMapping.RecordingMbid,
+	}
+	if metadata.MbidMapping.CaaReleaseMbid != "" && metadata.MbidMapping.CaaId != 0 {
+		song.CoverArtUrl = fmt.Sprintf("https://coverartarchive.org/release/%v/%v-250.jpg", metadata.MbidMapping.CaaReleaseMbid, metadata.MbidMapping.CaaId)
+	} else if metadata.MbidMapping.ReleaseMbid != "" {
+		song.CoverArtUrl = fmt.Sprintf("https://coverartarchive.org/release/%v/front-250", metadata.MbidMapping.ReleaseMbid)
+	}
+	return song
+}
+
+// Get the latest listenbrainz pinned recordings, the latest one first
+// Does a request to API_URL/USERNAME/pins?count=COUNT to get the latest pinned recordings
+// (even if they are expired, it will still take the latest)
+// Tries to get the recording_mbid from them and generate a music_link from it, otherwise,
+// we do another request to get the listens of that user and try to get the origin_url from there by comparing
+// the titles of the songs or the msid.
+func GetListenbrainzPinnedRecordings(ctx context.Context, api_url string, username string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/%v/pins?count=%v", strings.TrimSuffix(api_url, "/"), username, count)
+
+	var pinnedRecording ListenbrainzPinnedRecordings
+	err = sendRequestAndParseJSON(ctx, request, "https://listenbrainz.readthedocs.io/en/latest/users/api/recordings.html#get--1-(user_name)-pins", &pinnedRecording)
+	if err != nil {
+		return
+	}
+
+	if len(pinnedRecording.PinnedRecordings) == 0 {
+		err = errors.New("That listenbrainz user has never pinned a recording.")
+		return
+	}
+
+	for _, pin := range pinnedRecording.PinnedRecordings {
+		song := pin.TrackMetadata.song()
+
+		// If we already have a recording_mbid, use it to generate a music link
+		if song.RecordingMbid != "" {
+			song.Link = fmt.Sprintf("https://listenbrainz.org/track/%v", song.RecordingMbid)
+		} else {
+			song.Link, err = findListenbrainzOriginUrl(ctx, api_url, username, pin.Created, pin.RecordingMsid, pin.TrackMetadata)
+			if err != nil {
+				return
+			}
+		}
+		songs = append(songs, song)
+	}
+	return
+}
+
+// Find the origin_url of a recording by searching the listens of the user around the time it was created,
+// by comparing the msid, or as a fallback the track name and artist name
+func findListenbrainzOriginUrl(ctx context.Context, api_url string, username string, created int, msid string, metadata TrackMetadata) (origin_url string, err error) {
+	request := fmt.Sprintf("%v/user/%v/listens?max_ts=%v&count=200", strings.TrimSuffix(api_url, "/"), username, created+(60*60)) // Add 1 hour to the max_ts to have some headroom
+
+	var listens ListenbrainzListens
+	err = sendRequestAndParseJSON(ctx, request, "https://listenbrainz.readthedocs.io/en/latest/users/api/core.html#get--1-user-(user_name)-listens", &listens)
+	if err != nil {
+		return
+	}
+
+	for i := range listens.Payload.Listens {
+		listen := listens.Payload.Listens[i]
+		// Try to get the music link by finding the same msid, and as a fallback
+		// check for the same trackname/artistname
+		if msid != "" && listen.RecordingMsid == msid {
+			return listen.TrackMetadata.AdditionalInfo.OriginUrl, nil
+		} else if strings.EqualFold(listen.TrackMetadata.TrackName, metadata.TrackName) && strings.EqualFold(listen.TrackMetadata.ArtistName, metadata.ArtistName) {
+			return listen.TrackMetadata.AdditionalInfo.OriginUrl, nil
+		}
+	}
+
+	return
+}
+
+// Get the most listened recordings of the user over the range
+//
+// API documentation: https://listenbrainz.readthedocs.io/en/latest/users/api/statistics.html#get--1-stats-user-(user_name)-recordings
+func GetListenbrainzTopRecordings(ctx context.Context, api_url string, username string, stats_range string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/stats/user/%v/recordings?range=%v&count=%v", strings.TrimSuffix(api_url, "/"), username, stats_range, count)
+
+	var stats ListenbrainzRecordingStats
+	err = sendRequestAndParseJSON(ctx, request, "https://listenbrainz.readthedocs.io/en/latest/users/api/statistics.html#get--1-stats-user-(user_name)-recordings", &stats)
+	if err != nil {
+		return
+	}
+	if len(stats.Payload.Recordings) == 0 {
+		err = errors.New("Listenbrainz has no statistics for that user and range yet.")
+		return
+	}
+
+	for _, recording := range stats.Payload.Recordings {
+		metadata := TrackMetadata{
+			ArtistName:  recording.ArtistName,
+			TrackName:   recording.TrackName,
+			ReleaseName: recording.ReleaseName,
+			MbidMapping: MbidMapping{
+				RecordingMbid:  recording.RecordingMbid,
+				ReleaseMbid:    recording.ReleaseMbid,
+				CaaReleaseMbid: recording.CaaReleaseMbid,
+				CaaId:          recording.CaaId,
+			},
+		}
+		song := metadata.song()
+		song.PlayCount = recording.ListenCount
+		song.Period = stats_range
+		if song.RecordingMbid != "" {
+			song.Link = fmt.Sprintf("https://listenbrainz.org/track/%v", song.RecordingMbid)
+		}
+		songs = append(songs, song)
+	}
+	return
+}
+
+// Get the most listened artists of the user over the range
+//
+// API documentation: https://listenbrainz.readthedocs.io/en/latest/users/api/statistics.html#get--1-stats-user-(user_name)-artists
+func GetListenbrainzTopArtists(ctx context.Context, api_url string, username string, stats_range string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/stats/user/%v/artists?range=%v&count=%v", strings.TrimSuffix(api_url, "/"), username, stats_range, count)
+
+	var stats ListenbrainzArtistStats
+	err = sendRequestAndParseJSON(ctx, request, "https://listenbrainz.readthedocs.io/en/latest/users/api/statistics.html#get--1-stats-user-(user_name)-artists", &stats)
+	if err != nil {
+		return
+	}
+	if len(stats.Payload.Artists) == 0 {
+		err = errors.New("Listenbrainz has no statistics for that user and range yet.")
+		return
+	}
+
+	for _, artist := range stats.Payload.Artists {
+		song := Song{
+			Entity:    EntityArtist,
+			Artists:   []string{artist.ArtistName},
+			PlayCount: artist.ListenCount,
+			Period:    stats_range,
+			Provider:  Listenbrainz,
+		}
+		if artist.ArtistMbid != "" {
+			song.Link = fmt.Sprintf("https://listenbrainz.org/artist/%v", artist.ArtistMbid)
+		}
+		songs = append(songs, song)
+	}
+	return
+}
+
+// Get the most listened releases (albums) of the user over the range
+//
+// API documentation: https://listenbrainz.readthedocs.io/en/latest/users/api/statistics.html#get--1-stats-user-(user_name)-releases
+func GetListenbrainzTopReleases(ctx context.Context, api_url string, username string, stats_range string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/stats/user/%v/releases?range=%v&count=%v", strings.TrimSuffix(api_url, "/"), username, stats_range, count)
+
+	var stats ListenbrainzReleaseStats
+	err = sendRequestAndParseJSON(ctx, request, "https://listenbrainz.readthedocs.io/en/latest/users/api/statistics.html#get--1-stats-user-(user_name)-releases", &stats)
+	if err != nil {
+		return
+	}
+	if len(stats.Payload.Releases) == 0 {
+		err = errors.New("Listenbrainz has no statistics for that user and range yet.")
+		return
+	}
+
+	for _, release := range stats.Payload.Releases {
+		metadata := TrackMetadata{
+			ArtistName:  release.ArtistName,
+			ReleaseName: release.ReleaseName,
+			MbidMapping: MbidMapping{
+				ReleaseMbid:    release.ReleaseMbid,
+				CaaReleaseMbid: release.CaaReleaseMbid,
+				CaaId:          release.CaaId,
+			},
+		}
+		song := metadata.song()
+		song.Entity = EntityAlbum
+		song.PlayCount = release.ListenCount
+		song.Period = stats_range
+		if release.ReleaseMbid != "" {
+			song.Link = fmt.Sprintf("https://musicbrainz.org/release/%v", release.ReleaseMbid)
+		}
+		songs = append(songs, song)
+	}
+	return
+}
+
+// Get the latest recordings the user has loved, the latest one first
+//
+// API documentation: https://listenbrainz.readthedocs.io/en/latest/users/api/recordings.html#get--1-feedback-user-(user_name)-get-feedback
+func GetListenbrainzLovedRecordings(ctx context.Context, api_url string, username string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/feedback/user/%v/get-feedback?score=1&count=%v&metadata=true", strings.TrimSuffix(api_url, "/"), username, count)
+
+	var feedback ListenbrainzFeedback
+	err = sendRequestAndParseJSON(ctx, request, "https://listenbrainz.readthedocs.io/en/latest/users/api/recordings.html#get--1-feedback-user-(user_name)-get-feedback", &feedback)
+	if err != nil {
+		return
+	}
+	if len(feedback.Feedback) == 0 {
+		err = errors.New("That listenbrainz user has never loved a recording.")
+		return
+	}
+
+	for _, loved := range feedback.Feedback {
+		if loved.TrackMetadata.MbidMapping.RecordingMbid == "" {
+			loved.TrackMetadata.MbidMapping.RecordingMbid = loved.RecordingMbid
+		}
+		song := loved.TrackMetadata.song()
+		if song.RecordingMbid != "" {
+			song.Link = fmt.Sprintf("https://listenbrainz.org/track/%v", song.RecordingMbid)
+		} else {
+			song.Link, err = findListenbrainzOriginUrl(ctx, api_url, username, loved.Created, loved.RecordingMsid, loved.TrackMetadata)
+			if err != nil {
+				return
+			}
+		}
+		songs = append(songs, song)
+	}
+	return
+}
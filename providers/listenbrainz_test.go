@@ -0,0 +1,198 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const TEST_LISTENBRAINZ_PINS = `{"pinned_recordings": [
+	{"created": 1000, "recording_msid": "msid1", "track_metadata": {"artist_name": "A", "track_name": "One", "release_name": "First", "mbid_mapping": {"recording_mbid": "rec1", "caa_release_mbid": "caa1", "caa_id": 42}}},
+	{"created": 2000, "recording_msid": "msid2", "track_metadata": {"artist_name": "B", "track_name": "Two", "release_name": "Second"}}
+]}`
+
+// Listens of the user before the second pin, the link of the pin without mbid is taken from them
+const TEST_LISTENBRAINZ_LISTENS = `{"payload": {"listens": [
+	{"recording_msid": "other", "track_metadata": {"artist_name": "C", "track_name": "Three", "additional_info": {"origin_url": "https://example.com/three"}}},
+	{"recording_msid": "msid2", "track_metadata": {"artist_name": "B", "track_name": "Two", "additional_info": {"origin_url": "https://example.com/two"}}}
+]}}`
+
+const TEST_LISTENBRAINZ_RECORDINGS = `{"payload": {"recordings": [
+	{"artist_name": "A", "track_name": "One", "release_name": "First", "recording_mbid": "rec1", "release_mbid": "rel1", "listen_count": 12},
+	{"artist_name": "B", "track_name": "Two", "listen_count": 3}
+]}}`
+
+const TEST_LISTENBRAINZ_FEEDBACK = `{"feedback": [
+	{"created": 3000, "recording_mbid": "rec3", "recording_msid": "msid3", "track_metadata": {"artist_name": "C", "track_name": "Three", "mbid_mapping": {"release_mbid": "rel3"}}}
+]}`
+
+// Fake listenbrainz API for the user "user", the answers are indexed by the path, a missing path answers 204
+func fakeListenbrainz(t *testing.T, answers map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, found := strings.CutPrefix(r.URL.Path, "/1/")
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		switch path {
+		case "user/pins":
+			if query.Get("count") != "2" {
+				t.Errorf("Unexpected query %v", r.URL.RawQuery)
+			}
+		case "user/user/listens":
+			// One hour after the creation of the pin
+			if query.Get("max_ts") != "5600" {
+				t.Errorf("Unexpected query %v", r.URL.RawQuery)
+			}
+		case "stats/user/user/recordings", "stats/user/user/artists", "stats/user/user/releases":
+			if query.Get("range") != "this_month" || query.Get("count") != "2" {
+				t.Errorf("Unexpected query %v", r.URL.RawQuery)
+			}
+		case "feedback/user/user/get-feedback":
+			if query.Get("score") != "1" || query.Get("metadata") != "true" {
+				t.Errorf("Unexpected query %v", r.URL.RawQuery)
+			}
+		}
+		answer, ok := answers[path]
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprint(w, answer)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListenbrainzFetchList(t *testing.T) {
+	server := fakeListenbrainz(t, map[string]string{
+		"user/pins":                       TEST_LISTENBRAINZ_PINS,
+		"user/user/listens":               TEST_LISTENBRAINZ_LISTENS,
+		"stats/user/user/recordings":      TEST_LISTENBRAINZ_RECORDINGS,
+		"feedback/user/user/get-feedback": TEST_LISTENBRAINZ_FEEDBACK,
+	})
+
+	tests := []struct {
+		mode string
+		want []Song
+	}{
+		{
+			mode: ListenbrainzPinned,
+			want: []Song{
+				{Title: "One", Artists: []string{"A"}, Album: "First", Provider: Listenbrainz, RecordingMbid: "rec1", Link: "https://listenbrainz.org/track/rec1", CoverArtUrl: "https://coverartarchive.org/release/caa1/42-250.jpg"},
+				// Without mbid, the link is the origin_url of the listen with the same msid
+				{Title: "Two", Artists: []string{"B"}, Album: "Second", Provider: Listenbrainz, Link: "https://example.com/two"},
+			},
+		},
+		{
+			mode: ListenbrainzTop,
+			want: []Song{
+				{Title: "One", Artists: []string{"A"}, Album: "First", PlayCount: 12, Period: "this_month", Provider: Listenbrainz, RecordingMbid: "rec1", Link: "https://listenbrainz.org/track/rec1", CoverArtUrl: "https://coverartarchive.org/release/rel1/front-250"},
+				{Title: "Two", Artists: []string{"B"}, PlayCount: 3, Period: "this_month", Provider: Listenbrainz},
+			},
+		},
+		{
+			mode: ListenbrainzLoved,
+			want: []Song{
+				// The recording_mbid of the feedback is used when the metadata doesn't have one
+				{Title: "Three", Artists: []string{"C"}, Provider: Listenbrainz, RecordingMbid: "rec3", Link: "https://listenbrainz.org/track/rec3", CoverArtUrl: "https://coverartarchive.org/release/rel3/front-250"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			provider := ListenbrainzProvider{Username: "user", Mode: test.mode, Range: "this_month", ApiUrl: server.URL + "/1/"}
+			songs, err := provider.FetchList(context.Background(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(songs, test.want) {
+				t.Errorf("Got %+v, want %+v", songs, test.want)
+			}
+		})
+	}
+}
+
+func TestListenbrainzErrors(t *testing.T) {
+	// Every request answers 204, like the statistics of a user that doesn't have any yet
+	server := fakeListenbrainz(t, map[string]string{"user/pins": `{"pinned_recordings": []}`})
+
+	tests := []struct {
+		mode string
+		err  string
+	}{
+		{ListenbrainzPinned, "never pinned"},
+		{ListenbrainzTop, "no statistics"},
+		{ListenbrainzLoved, "never loved"},
+	}
+	for _, test := range tests {
+		provider := ListenbrainzProvider{Username: "user", Mode: test.mode, Range: "this_month", ApiUrl: server.URL + "/1"}
+		_, err := provider.FetchList(context.Background(), 2)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%v: got the error %v, want one with %q", test.mode, err, test.err)
+		}
+	}
+}
+
+const TEST_LISTENBRAINZ_ARTISTS = `{"payload": {"artists": [
+	{"artist_name": "A", "artist_mbid": "art1", "listen_count": 40},
+	{"artist_name": "B", "listen_count": 8}
+]}}`
+
+const TEST_LISTENBRAINZ_RELEASES = `{"payload": {"releases": [
+	{"artist_name": "A", "release_name": "First", "release_mbid": "rel1", "caa_release_mbid": "caa1", "caa_id": 7, "listen_count": 25}
+]}}`
+
+func TestListenbrainzEntities(t *testing.T) {
+	server := fakeListenbrainz(t, map[string]string{
+		"stats/user/user/artists":  TEST_LISTENBRAINZ_ARTISTS,
+		"stats/user/user/releases": TEST_LISTENBRAINZ_RELEASES,
+	})
+
+	tests := []struct {
+		entity Entity
+		want   []Song
+	}{
+		{
+			entity: EntityArtist,
+			want: []Song{
+				{Entity: EntityArtist, Artists: []string{"A"}, PlayCount: 40, Period: "this_month", Provider: Listenbrainz, Link: "https://listenbrainz.org/artist/art1"},
+				{Entity: EntityArtist, Artists: []string{"B"}, PlayCount: 8, Period: "this_month", Provider: Listenbrainz},
+			},
+		},
+		{
+			entity: EntityAlbum,
+			want: []Song{
+				{Entity: EntityAlbum, Artists: []string{"A"}, Album: "First", PlayCount: 25, Period: "this_month", Provider: Listenbrainz, Link: "https://musicbrainz.org/release/rel1", CoverArtUrl: "https://coverartarchive.org/release/caa1/7-250.jpg"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.entity), func(t *testing.T) {
+			provider := ListenbrainzProvider{Username: "user", Mode: ListenbrainzTop, Range: "this_month", ApiUrl: server.URL + "/1"}
+			if err := provider.SetEntity(test.entity); err != nil {
+				t.Fatal(err)
+			}
+			songs, err := provider.FetchList(context.Background(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(songs, test.want) {
+				t.Errorf("Got %+v, want %+v", songs, test.want)
+			}
+		})
+	}
+
+	// Only the top mode knows about the artists and albums
+	for _, mode := range []string{ListenbrainzPinned, ListenbrainzLoved} {
+		provider := ListenbrainzProvider{Mode: mode}
+		if err := provider.SetEntity(EntityAlbum); err == nil {
+			t.Errorf("%v: no error for the favorite album", mode)
+		}
+	}
+}
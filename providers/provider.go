@@ -0,0 +1,198 @@
+// Providers are where the favorite music is fetched from (last.fm, listenbrainz, youtube...)
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Type of a provider
+type ProviderType string
+
+// What the favorite is, a track, an artist or an album
+type Entity string
+
+const (
+	EntityTrack  Entity = "track"
+	EntityArtist Entity = "artist"
+	EntityAlbum  Entity = "album"
+)
+
+// A song returned by a provider
+//
+// Only the Title and the Artists are always set, the other fields are
+// filled in when the provider knows about them.
+//
+// When Entity is EntityArtist, only the Artists are set, and when it is EntityAlbum
+// the Album is set instead of the Title.
+type Song struct {
+	Entity  Entity
+	Title   string
+	Artists []string
+	Album   string
+	// How many times the song has been listened to during Period, 0 if unknown
+	PlayCount int
+	// Period over which the song is the favorite (7day, all_time, etc...)
+	Period string
+	// Provider that answered
+	Provider ProviderType
+	// Canonical link to the song
+	Link          string
+	RecordingMbid string
+	CoverArtUrl   string
+	// The song is the latest one the user listened to instead of a favorite
+	Recent bool
+	// The user is listening to the song right now
+	NowPlaying bool
+	// Position of the song inside of a top list (starting at 1), 0 if a single song was fetched
+	Rank int
+}
+
+// Every artist of the song separated by a comma
+func (song Song) Author() string {
+	return strings.Join(song.Artists, ", ")
+}
+
+// A provider where we can get the favorite music from
+//
+// Each provider registers its own flags, so that adding a new provider
+// does not require touching config.Parse.
+type Provider interface {
+	// Name of the provider, this is what is used inside of --fallback
+	Name() ProviderType
+
+	// Register the flags used to configure the provider
+	RegisterFlags(flags *flag.FlagSet)
+
+	// Whether the user gave enough information to use this provider
+	Configured() bool
+
+	// Check that the configuration of the provider is correct
+	Validate() error
+
+	// Fetch the favorite music
+	Fetch(ctx context.Context) (song Song, err error)
+}
+
+// Implemented by the providers that can also get the favorite artist or album
+type EntityProvider interface {
+	// Fetch the favorite entity instead of the favorite track, returns an error
+	// if the provider can't with its current configuration
+	SetEntity(entity Entity) error
+}
+
+// Implemented by the providers that can get an ordered list of favorites
+type ListProvider interface {
+	// Fetch up to count favorites, the first one being the favorite. Less songs
+	// are returned if the provider doesn't know about that many.
+	FetchList(ctx context.Context, count int) (songs []Song, err error)
+}
+
+// Implemented by the provider that takes the argument given without a flag (the deprecated
+// youtube channel id), only one provider can implement it
+type ArgumentProvider interface {
+	// Configure the provider with the argument, returns an error if it is already configured by its flag
+	SetArgument(argument string) error
+}
+
+// Create a new empty provider that is ready to register its flags
+type ProviderFactory func() Provider
+
+var providerFactories = map[ProviderType]ProviderFactory{}
+
+// Keep the registration order so that flags and errors are shown in a stable order
+var providerNames []ProviderType
+
+// Register a new provider, this is usually called inside of an init function
+//
+// Panics if a provider with the same name has already been registered.
+func Register(name ProviderType, factory ProviderFactory) {
+	if _, exists := providerFactories[name]; exists {
+		panic(fmt.Sprintf("provider %q registered twice", name))
+	}
+	providerFactories[name] = factory
+	providerNames = append(providerNames, name)
+}
+
+// Names of all of the registered providers
+func Registered() []ProviderType {
+	return append([]ProviderType(nil), providerNames...)
+}
+
+// Whether a provider with that name has been registered
+func IsRegistered(name ProviderType) bool {
+	_, exists := providerFactories[name]
+	return exists
+}
+
+// Create a new instance of every registered provider, in registration order
+func New() (providers []Provider) {
+	for _, name := range providerNames {
+		providers = append(providers, providerFactories[name]())
+	}
+	return
+}
+
+// Take the first song of a list, used by the providers to implement Fetch with FetchList
+func firstSong(songs []Song, err error) (Song, error) {
+	if err != nil {
+		return Song{}, err
+	}
+	if len(songs) == 0 {
+		return Song{}, errors.New("The provider did not return any song.")
+	}
+	return songs[0], nil
+}
+
+// Send a GET request to the URL with, expects a STATUS_OK, and decodes the v as json.
+//
+// v is left empty when the server answers STATUS_NO_CONTENT.
+func sendRequestAndParseJSON(ctx context.Context, request string, error_message_link string, v any) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, request, nil)
+	if err != nil {
+		return
+	}
+
+	return doRequestAndParseJSON(req, error_message_link, v)
+}
+
+// Send the request, expects a STATUS_OK, and decodes the v as json.
+//
+// Used when the request needs another method than GET or some headers. v is left empty when the
+// server answers STATUS_NO_CONTENT, like the listenbrainz statistics of a user that has none yet.
+func doRequestAndParseJSON(req *http.Request, error_message_link string, v any) (err error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var content []byte
+		content, err = io.ReadAll(resp.Body)
+		if err == nil {
+			log.Println(string(content))
+		} else {
+			log.Println("Couldn't get the error message.")
+			log.Println(err)
+		}
+
+		log.Printf("Get the corresponding error number from %v\n", error_message_link)
+		err = errors.New(fmt.Sprint("Request failed with status:", resp.Status))
+		return
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&v)
+	return
+}
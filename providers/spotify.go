@@ -0,0 +1,177 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const Spotify ProviderType = "spotify"
+
+func init() {
+	Register(Spotify, func() Provider { return &SpotifyProvider{} })
+}
+
+// Get the top track of a spotify user from the Web API
+//
+// A refresh token (with the user-top-read scope) is used to get a new access token on every run.
+type SpotifyProvider struct {
+	ClientId     string
+	ClientSecret string
+	RefreshToken string
+	// short_term (~4 weeks), medium_term (~6 months) or long_term (~1 year)
+	TimeRange string
+	// Can be changed to test against a local server
+	ApiUrl      string
+	AccountsUrl string
+}
+
+func (p *SpotifyProvider) Name() ProviderType {
+	return Spotify
+}
+
+func (p *SpotifyProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.ClientId, "spotifyClientId", "", "Client ID of the spotify application used to get the top track.")
+	flags.StringVar(&p.ClientSecret, "spotifyClientSecret", "", "Client secret of the spotify application, prefer the FAVORITE_MUSIC_BADGE_SPOTIFY_CLIENT_SECRET environment variable over this flag.")
+	flags.StringVar(&p.RefreshToken, "spotifyRefreshToken", "", "Spotify refresh token with the user-top-read scope, prefer the FAVORITE_MUSIC_BADGE_SPOTIFY_REFRESH_TOKEN environment variable over this flag.")
+	flags.StringVar(&p.TimeRange, "spotifyTimeRange", "short_term", "Spotify time range over which to retrieve the top track for (short_term, medium_term or long_term).")
+	flags.StringVar(&p.ApiUrl, "spotifyApiUrl", "https://api.spotify.com/v1", "Base URL of the spotify Web API.")
+	flags.StringVar(&p.AccountsUrl, "spotifyAccountsUrl", "https://accounts.spotify.com", "Base URL of the spotify accounts service, used to refresh the access token.")
+}
+
+func (p *SpotifyProvider) Configured() bool {
+	return p.RefreshToken != ""
+}
+
+func (p *SpotifyProvider) Validate() error {
+	if p.ClientId == "" || p.ClientSecret == "" {
+		return errors.New("If the spotifyRefreshToken flag is given, the spotifyClientId and spotifyClientSecret flags must also be given.")
+	}
+	switch p.TimeRange {
+	case "short_term", "medium_term", "long_term":
+	default:
+		return fmt.Errorf("Unknown spotify time range \"%v\", short_term, medium_term and long_term are the valid time ranges.", p.TimeRange)
+	}
+	return nil
+}
+
+func (p *SpotifyProvider) Fetch(ctx context.Context) (song Song, err error) {
+	return firstSong(p.FetchList(ctx, 1))
+}
+
+func (p *SpotifyProvider) FetchList(ctx context.Context, count int) (songs []Song, err error) {
+	access_token, err := GetSpotifyAccessToken(ctx, p.AccountsUrl, p.ClientId, p.ClientSecret, p.RefreshToken)
+	if err != nil {
+		return
+	}
+	return GetTopTracksFromSpotify(ctx, p.ApiUrl, access_token, p.TimeRange, count)
+}
+
+type SpotifyToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+type SpotifyTopTracks struct {
+	Items []SpotifyTrack `json:"items"`
+}
+
+type SpotifyTrack struct {
+	Name         string              `json:"name"`
+	Artists      []SpotifyArtist     `json:"artists"`
+	Album        SpotifyAlbum        `json:"album"`
+	ExternalUrls SpotifyExternalUrls `json:"external_urls"`
+}
+
+type SpotifyArtist struct {
+	Name string `json:"name"`
+}
+
+type SpotifyAlbum struct {
+	Name   string         `json:"name"`
+	Images []SpotifyImage `json:"images"`
+}
+
+// Spotify images are ordered from the widest to the smallest
+type SpotifyImage struct {
+	Url string `json:"url"`
+}
+
+type SpotifyExternalUrls struct {
+	Spotify string `json:"spotify"`
+}
+
+// Get a new access token from a refresh token
+//
+// API documentation: https://developer.spotify.com/documentation/web-api/tutorials/refreshing-tokens
+func GetSpotifyAccessToken(ctx context.Context, accounts_url string, client_id string, client_secret string, refresh_token string) (access_token string, err error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refresh_token)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(accounts_url, "/")+"/api/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(client_id, client_secret)
+
+	var token SpotifyToken
+	err = doRequestAndParseJSON(req, "https://developer.spotify.com/documentation/web-api/tutorials/refreshing-tokens", &token)
+	if err != nil {
+		return
+	}
+	if token.AccessToken == "" {
+		err = errors.New("Spotify did not return an access token.")
+		return
+	}
+	return token.AccessToken, nil
+}
+
+// Get the top tracks of the user that owns the access token
+//
+// API documentation: https://developer.spotify.com/documentation/web-api/reference/get-users-top-artists-and-tracks
+func GetTopTracksFromSpotify(ctx context.Context, api_url string, access_token string, time_range string, count int) (songs []Song, err error) {
+	request := fmt.Sprintf("%v/me/top/tracks?time_range=%v&limit=%v", strings.TrimSuffix(api_url, "/"), url.QueryEscape(time_range), count)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, request, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+access_token)
+
+	var topTracks SpotifyTopTracks
+	err = doRequestAndParseJSON(req, "https://developer.spotify.com/documentation/web-api/concepts/api-calls#response-status-codes", &topTracks)
+	if err != nil {
+		return
+	}
+	if len(topTracks.Items) == 0 {
+		err = errors.New("No top tracks were found on spotify for that time range.")
+		return
+	}
+
+	for _, track := range topTracks.Items {
+		songs = append(songs, track.song(time_range))
+	}
+	return
+}
+
+// Convert a spotify track to a song
+func (track SpotifyTrack) song(time_range string) Song {
+	song := Song{
+		Title:    track.Name,
+		Album:    track.Album.Name,
+		Period:   time_range,
+		Provider: Spotify,
+		Link:     track.ExternalUrls.Spotify,
+	}
+	for i := range track.Artists {
+		song.Artists = append(song.Artists, track.Artists[i].Name)
+	}
+	if len(track.Album.Images) > 0 {
+		song.CoverArtUrl = track.Album.Images[0].Url
+	}
+	return song
+}
@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const SpotifyHistory ProviderType = "spotify-history"
+
+// Spotify counts a stream when at least 30 seconds have been played
+const spotifyStreamThreshold = 30 * 1000
+
+func init() {
+	Register(SpotifyHistory, func() Provider { return &SpotifyHistoryProvider{} })
+}
+
+// Get the most listened track from the "Extended streaming history" export of spotify
+//
+// Works offline, the files can be downloaded from https://www.spotify.com/account/privacy/
+type SpotifyHistoryProvider struct {
+	// Comma separated list of files, globs or directories
+	Files  string
+	Period string
+	Entity Entity
+}
+
+func (p *SpotifyHistoryProvider) Name() ProviderType {
+	return SpotifyHistory
+}
+
+func (p *SpotifyHistoryProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.Files, "spotifyHistory", "", "Spotify extended streaming history files (Streaming_History_Audio_*.json), separated by ','. Globs and directories containing the files can also be given.")
+	flags.StringVar(&p.Period, "spotifyHistoryPeriod", PeriodOverall, "Period over which to retrieve the top track from the spotify history (7day, 1month, 3month, 6month, 12month or overall), counted back from the latest stream.")
+}
+
+func (p *SpotifyHistoryProvider) Configured() bool {
+	return p.Files != ""
+}
+
+func (p *SpotifyHistoryProvider) Validate() error {
+	return validateHistoryPeriod(p.Period)
+}
+
+func (p *SpotifyHistoryProvider) Fetch(ctx context.Context) (song Song, err error) {
+	return firstSong(p.FetchList(ctx, 1))
+}
+
+func (p *SpotifyHistoryProvider) FetchList(ctx context.Context, count int) (songs []Song, err error) {
+	files, err := expandHistoryFiles(p.Files, "Streaming_History_Audio_*.json")
+	if err != nil {
+		return
+	}
+
+	listens, err := ReadSpotifyHistory(files)
+	if err != nil {
+		return
+	}
+	return topFromHistory(listens, p.Period, p.Entity, count)
+}
+
+func (p *SpotifyHistoryProvider) SetEntity(entity Entity) error {
+	p.Entity = entity
+	return nil
+}
+
+// One stream inside of Streaming_History_Audio_*.json
+type SpotifyStream struct {
+	Timestamp  string `json:"ts"`
+	MsPlayed   int64  `json:"ms_played"`
+	TrackName  string `json:"master_metadata_track_name"`
+	ArtistName string `json:"master_metadata_album_artist_name"`
+	AlbumName  string `json:"master_metadata_album_album_name"`
+	TrackUri   string `json:"spotify_track_uri"`
+}
+
+// Read the streams of every file, podcasts and audiobooks are skipped
+func ReadSpotifyHistory(files []string) (listens []historyListen, err error) {
+	for _, file := range files {
+		var content []byte
+		content, err = os.ReadFile(file)
+		if err != nil {
+			return
+		}
+
+		var streams []SpotifyStream
+		err = json.Unmarshal(content, &streams)
+		if err != nil {
+			return nil, fmt.Errorf("While parsing the spotify history %v: %w", file, err)
+		}
+
+		for _, stream := range streams {
+			if stream.TrackName == "" || stream.TrackUri == "" {
+				continue
+			}
+
+			var listened_at time.Time
+			listened_at, err = time.Parse(time.RFC3339, stream.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid timestamp inside of the spotify history %v: %w", file, err)
+			}
+
+			listens = append(listens, historyListen{
+				Time: listened_at,
+				Song: Song{
+					Title:    stream.TrackName,
+					Artists:  []string{stream.ArtistName},
+					Album:    stream.AlbumName,
+					Provider: SpotifyHistory,
+					Link:     "https://open.spotify.com/track/" + strings.TrimPrefix(stream.TrackUri, "spotify:track:"),
+				},
+				Key:    stream.TrackUri,
+				Weight: stream.MsPlayed,
+				Played: stream.MsPlayed >= spotifyStreamThreshold,
+			})
+		}
+	}
+	return
+}
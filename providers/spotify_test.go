@@ -0,0 +1,109 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const TEST_SPOTIFY_TOP_TRACKS = `{"items": [
+	{"name": "First", "artists": [{"name": "A"}, {"name": "B"}], "album": {"name": "Album", "images": [{"url": "https://i.scdn.co/big"}, {"url": "https://i.scdn.co/small"}]}, "external_urls": {"spotify": "https://open.spotify.com/track/1"}},
+	{"name": "Second", "artists": [{"name": "C"}], "album": {"name": "Other", "images": []}, "external_urls": {"spotify": "https://open.spotify.com/track/2"}}
+]}`
+
+// Fake spotify accounts service and Web API, top_tracks is the answer of the top tracks
+func fakeSpotify(t *testing.T, top_tracks string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/accounts/api/token":
+			client_id, client_secret, ok := r.BasicAuth()
+			if !ok || client_id != "id" || client_secret != "secret" {
+				http.Error(w, `{"error": "invalid_client"}`, http.StatusBadRequest)
+				return
+			}
+			if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh" {
+				http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"access_token": "access", "token_type": "Bearer"}`))
+		case "/v1/me/top/tracks":
+			if r.Header.Get("Authorization") != "Bearer access" {
+				http.Error(w, `{"error": {"status": 401}}`, http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("time_range") != "medium_term" || r.URL.Query().Get("limit") != "2" {
+				t.Errorf("Unexpected query %v", r.URL.RawQuery)
+			}
+			w.Write([]byte(top_tracks))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSpotifyFetchList(t *testing.T) {
+	server := fakeSpotify(t, TEST_SPOTIFY_TOP_TRACKS)
+	provider := SpotifyProvider{
+		ClientId:     "id",
+		ClientSecret: "secret",
+		RefreshToken: "refresh",
+		TimeRange:    "medium_term",
+		ApiUrl:       server.URL + "/v1/",
+		AccountsUrl:  server.URL + "/accounts",
+	}
+
+	songs, err := provider.FetchList(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Song{
+		{Title: "First", Artists: []string{"A", "B"}, Album: "Album", Period: "medium_term", Provider: Spotify, Link: "https://open.spotify.com/track/1", CoverArtUrl: "https://i.scdn.co/big"},
+		{Title: "Second", Artists: []string{"C"}, Album: "Other", Period: "medium_term", Provider: Spotify, Link: "https://open.spotify.com/track/2"},
+	}
+	if !reflect.DeepEqual(songs, want) {
+		t.Errorf("Got %+v, want %+v", songs, want)
+	}
+}
+
+func TestSpotifyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		top_tracks string
+		secret     string
+		err        string
+	}{
+		{"wrong secret", TEST_SPOTIFY_TOP_TRACKS, "wrong", "400"},
+		{"no top tracks", `{"items": []}`, "secret", "No top tracks"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := fakeSpotify(t, test.top_tracks)
+			provider := SpotifyProvider{ClientId: "id", ClientSecret: test.secret, RefreshToken: "refresh", TimeRange: "medium_term", ApiUrl: server.URL + "/v1", AccountsUrl: server.URL + "/accounts"}
+			_, err := provider.FetchList(context.Background(), 2)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Got the error %v, want one with %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSpotifyValidate(t *testing.T) {
+	tests := []struct {
+		provider SpotifyProvider
+		valid    bool
+	}{
+		{SpotifyProvider{ClientId: "id", ClientSecret: "secret", RefreshToken: "refresh", TimeRange: "short_term"}, true},
+		{SpotifyProvider{ClientId: "id", RefreshToken: "refresh", TimeRange: "short_term"}, false},
+		{SpotifyProvider{ClientId: "id", ClientSecret: "secret", RefreshToken: "refresh", TimeRange: "forever"}, false},
+	}
+	for _, test := range tests {
+		if err := test.provider.Validate(); (err == nil) != test.valid {
+			t.Errorf("%+v: got %v, want valid %v", test.provider, err, test.valid)
+		}
+	}
+}
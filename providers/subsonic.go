@@ -0,0 +1,290 @@
+package providers
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const Subsonic ProviderType = "subsonic"
+
+// Modes of the subsonic provider
+const (
+	// Most played song of the most played albums
+	SubsonicPlayed = "played"
+	// Most recently starred song
+	SubsonicStarred = "starred"
+)
+
+// Version of the subsonic API that we use, supported by navidrome, airsonic and gonic
+const SUBSONIC_API_VERSION = "1.16.1"
+
+// How many of the most played albums are searched for the most played song
+const subsonicAlbumCount = 10
+
+func init() {
+	Register(Subsonic, func() Provider { return &SubsonicProvider{} })
+}
+
+// Get the most played or starred song from a subsonic/opensubsonic server (navidrome, airsonic, gonic...)
+type SubsonicProvider struct {
+	Url      string
+	Username string
+	Password string
+	Mode     string
+	// Create (or reuse) a share of the song to link to it
+	Share bool
+}
+
+func (p *SubsonicProvider) Name() ProviderType {
+	return Subsonic
+}
+
+func (p *SubsonicProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.Url, "subsonicUrl", "", "Base URL of the subsonic server (navidrome, airsonic, gonic...) where we get the favorite song from.")
+	flags.StringVar(&p.Username, "subsonicUsername", "", "Username on the subsonic server.")
+	flags.StringVar(&p.Password, "subsonicPassword", "", "Password on the subsonic server, prefer the FAVORITE_MUSIC_BADGE_SUBSONIC_PASSWORD environment variable over this flag.")
+	flags.StringVar(&p.Mode, "subsonicMode", SubsonicPlayed, "\"played\" takes the most played song, \"starred\" the most recently starred song.")
+	flags.BoolVar(&p.Share, "subsonicShare", true, "Link to a share of the song on the subsonic server, sharing must be enabled on the server.")
+}
+
+func (p *SubsonicProvider) Configured() bool {
+	return p.Url != ""
+}
+
+func (p *SubsonicProvider) Validate() error {
+	if p.Username == "" || p.Password == "" {
+		return errors.New("If the subsonicUrl flag is given, the subsonicUsername and subsonicPassword flags must also be given.")
+	}
+	if p.Mode != SubsonicPlayed && p.Mode != SubsonicStarred {
+		return fmt.Errorf("Unknown subsonic mode \"%v\", \"%v\" and \"%v\" are the valid modes.", p.Mode, SubsonicPlayed, SubsonicStarred)
+	}
+	return nil
+}
+
+func (p *SubsonicProvider) Fetch(ctx context.Context) (song Song, err error) {
+	return firstSong(p.FetchList(ctx, 1))
+}
+
+func (p *SubsonicProvider) FetchList(ctx context.Context, count int) (songs []Song, err error) {
+	client := SubsonicClient{Url: p.Url, Username: p.Username, Password: p.Password}
+
+	var subsonic_songs []SubsonicSong
+	switch p.Mode {
+	case SubsonicStarred:
+		subsonic_songs, err = client.GetLatestStarredSongs(ctx, count)
+	default:
+		subsonic_songs, err = client.GetMostPlayedSongs(ctx, count)
+	}
+	if err != nil {
+		return
+	}
+
+	for _, subsonic_song := range subsonic_songs {
+		song := subsonic_song.song()
+		if p.Mode == SubsonicPlayed {
+			song.Period = "all_time"
+		}
+
+		if p.Share {
+			song.Link, err = client.GetShareUrl(ctx, subsonic_song.Id)
+			if err != nil {
+				// The song is still correct without a link
+				log.Print(err)
+				log.Print("Couldn't create a share of the song on the subsonic server, is sharing enabled?")
+				err = nil
+			}
+		}
+		songs = append(songs, song)
+	}
+	return
+}
+
+// Client for the subsonic API using the token and salt authentication
+//
+// API documentation: https://www.subsonic.org/pages/api.jsp
+type SubsonicClient struct {
+	Url      string
+	Username string
+	Password string
+}
+
+// Every subsonic response is wrapped inside of subsonic-response
+type SubsonicEnvelope struct {
+	Response SubsonicResponse `json:"subsonic-response"`
+}
+
+type SubsonicResponse struct {
+	Status     string             `json:"status"`
+	Error      SubsonicError      `json:"error"`
+	AlbumList2 SubsonicAlbumList2 `json:"albumList2"`
+	Album      SubsonicAlbum      `json:"album"`
+	Starred2   SubsonicStarred2   `json:"starred2"`
+	Shares     SubsonicShares     `json:"shares"`
+}
+
+type SubsonicError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type SubsonicAlbumList2 struct {
+	Album []SubsonicAlbum `json:"album"`
+}
+
+type SubsonicAlbum struct {
+	Id   string         `json:"id"`
+	Song []SubsonicSong `json:"song"`
+}
+
+type SubsonicStarred2 struct {
+	Song []SubsonicSong `json:"song"`
+}
+
+type SubsonicShares struct {
+	Share []SubsonicShare `json:"share"`
+}
+
+type SubsonicShare struct {
+	Url   string         `json:"url"`
+	Entry []SubsonicSong `json:"entry"`
+}
+
+type SubsonicSong struct {
+	Id            string `json:"id"`
+	Title         string `json:"title"`
+	Artist        string `json:"artist"`
+	Album         string `json:"album"`
+	PlayCount     int    `json:"playCount"`
+	Starred       string `json:"starred"`
+	MusicBrainzId string `json:"musicBrainzId"`
+}
+
+// Convert a subsonic song to a song, without a link
+func (subsonic_song SubsonicSong) song() Song {
+	return Song{
+		Title:         subsonic_song.Title,
+		Artists:       []string{subsonic_song.Artist},
+		Album:         subsonic_song.Album,
+		PlayCount:     subsonic_song.PlayCount,
+		Provider:      Subsonic,
+		RecordingMbid: subsonic_song.MusicBrainzId,
+	}
+}
+
+// Call a subsonic endpoint (getAlbumList2, getStarred2...) with the authentication parameters
+func (client SubsonicClient) call(ctx context.Context, endpoint string, parameters url.Values) (response SubsonicResponse, err error) {
+	salt_bytes := make([]byte, 8)
+	_, err = rand.Read(salt_bytes)
+	if err != nil {
+		return
+	}
+	salt := hex.EncodeToString(salt_bytes)
+	token := md5.Sum([]byte(client.Password + salt))
+
+	if parameters == nil {
+		parameters = url.Values{}
+	}
+	parameters.Set("u", client.Username)
+	parameters.Set("t", hex.EncodeToString(token[:]))
+	parameters.Set("s", salt)
+	parameters.Set("v", SUBSONIC_API_VERSION)
+	parameters.Set("c", "favorite_music_badge")
+	parameters.Set("f", "json")
+
+	request := fmt.Sprintf("%v/rest/%v?%v", strings.TrimSuffix(client.Url, "/"), endpoint, parameters.Encode())
+	var envelope SubsonicEnvelope
+	err = sendRequestAndParseJSON(ctx, request, "https://www.subsonic.org/pages/api.jsp", &envelope)
+	if err != nil {
+		return
+	}
+
+	// Subsonic errors are returned with a 200 status
+	response = envelope.Response
+	if response.Status != "ok" {
+		err = fmt.Errorf("Subsonic %v failed with the error %v: %v", endpoint, response.Error.Code, response.Error.Message)
+	}
+	return
+}
+
+// Get the count songs with the most plays inside of the most played albums
+func (client SubsonicClient) GetMostPlayedSongs(ctx context.Context, count int) (most_played []SubsonicSong, err error) {
+	albums, err := client.call(ctx, "getAlbumList2", url.Values{"type": {"frequent"}, "size": {fmt.Sprint(max(subsonicAlbumCount, count))}})
+	if err != nil {
+		return
+	}
+
+	for _, album := range albums.AlbumList2.Album {
+		var response SubsonicResponse
+		response, err = client.call(ctx, "getAlbum", url.Values{"id": {album.Id}})
+		if err != nil {
+			return
+		}
+		for _, subsonic_song := range response.Album.Song {
+			if subsonic_song.PlayCount > 0 {
+				most_played = append(most_played, subsonic_song)
+			}
+		}
+	}
+
+	if len(most_played) == 0 {
+		err = errors.New("No played songs were found on the subsonic server.")
+		return
+	}
+
+	sort.SliceStable(most_played, func(i, j int) bool {
+		return most_played[i].PlayCount > most_played[j].PlayCount
+	})
+	return most_played[:min(count, len(most_played))], nil
+}
+
+// Get the count songs that were starred last, the latest one first
+func (client SubsonicClient) GetLatestStarredSongs(ctx context.Context, count int) (latest []SubsonicSong, err error) {
+	response, err := client.call(ctx, "getStarred2", nil)
+	if err != nil {
+		return
+	}
+
+	latest = response.Starred2.Song
+	if len(latest) == 0 {
+		err = errors.New("No starred songs were found on the subsonic server.")
+		return
+	}
+
+	// Dates are in ISO 8601, they can be compared as strings
+	sort.SliceStable(latest, func(i, j int) bool {
+		return latest[i].Starred > latest[j].Starred
+	})
+	return latest[:min(count, len(latest))], nil
+}
+
+// Get the url of a share of the song, an existing share is reused so that we don't create one on every run
+func (client SubsonicClient) GetShareUrl(ctx context.Context, song_id string) (share_url string, err error) {
+	response, err := client.call(ctx, "getShares", nil)
+	if err != nil {
+		return
+	}
+	for _, share := range response.Shares.Share {
+		if len(share.Entry) == 1 && share.Entry[0].Id == song_id {
+			return share.Url, nil
+		}
+	}
+
+	response, err = client.call(ctx, "createShare", url.Values{"id": {song_id}, "description": {"favorite_music_badge"}})
+	if err != nil {
+		return
+	}
+	if len(response.Shares.Share) == 0 {
+		err = errors.New("The subsonic server did not return the created share.")
+		return
+	}
+	return response.Shares.Share[0].Url, nil
+}
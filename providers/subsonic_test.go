@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Fake subsonic server with two albums, two starred songs and a share of the song 2
+type fakeSubsonic struct {
+	// Endpoints that were called, in order
+	calls []string
+	// Answer every call with an error
+	failing bool
+}
+
+func (fake *fakeSubsonic) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimPrefix(r.URL.Path, "/music/rest/")
+		fake.calls = append(fake.calls, endpoint)
+		query := r.URL.Query()
+
+		token := md5.Sum([]byte("password" + query.Get("s")))
+		if query.Get("u") != "user" || query.Get("t") != hex.EncodeToString(token[:]) || query.Get("f") != "json" {
+			fmt.Fprint(w, `{"subsonic-response": {"status": "failed", "error": {"code": 40, "message": "Wrong username or password"}}}`)
+			return
+		}
+		if fake.failing {
+			fmt.Fprint(w, `{"subsonic-response": {"status": "failed", "error": {"code": 0, "message": "Broken"}}}`)
+			return
+		}
+
+		var response string
+		switch endpoint {
+		case "getAlbumList2":
+			if query.Get("type") != "frequent" {
+				t.Errorf("Unexpected album list %v", query.Get("type"))
+			}
+			response = `"albumList2": {"album": [{"id": "a1"}, {"id": "a2"}]}`
+		case "getAlbum":
+			songs := map[string]string{
+				"a1": `{"id": "1", "title": "One", "artist": "A", "album": "First", "playCount": 3}, {"id": "4", "title": "Never", "artist": "A", "album": "First"}`,
+				"a2": `{"id": "2", "title": "Two", "artist": "B", "album": "Second", "playCount": 7, "musicBrainzId": "mbid"}, {"id": "3", "title": "Three", "artist": "B", "album": "Second", "playCount": 1}`,
+			}
+			response = fmt.Sprintf(`"album": {"id": %q, "song": [%v]}`, query.Get("id"), songs[query.Get("id")])
+		case "getStarred2":
+			response = `"starred2": {"song": [
+				{"id": "1", "title": "One", "artist": "A", "starred": "2024-01-01T10:00:00Z"},
+				{"id": "3", "title": "Three", "artist": "B", "starred": "2025-06-01T10:00:00Z"}
+			]}`
+		case "getShares":
+			response = `"shares": {"share": [{"url": "https://music.test/share/2", "entry": [{"id": "2"}]}, {"url": "https://music.test/share/many", "entry": [{"id": "1"}, {"id": "3"}]}]}`
+		case "createShare":
+			response = fmt.Sprintf(`"shares": {"share": [{"url": "https://music.test/share/new-%v"}]}`, query.Get("id"))
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"subsonic-response": {"status": "ok", %v}}`, response)
+	})
+}
+
+func TestSubsonicFetchList(t *testing.T) {
+	tests := []struct {
+		mode  string
+		share bool
+		count int
+		want  []Song
+		calls []string
+	}{
+		{
+			mode: SubsonicPlayed, count: 2,
+			want: []Song{
+				{Title: "Two", Artists: []string{"B"}, Album: "Second", PlayCount: 7, Period: "all_time", Provider: Subsonic, RecordingMbid: "mbid"},
+				{Title: "One", Artists: []string{"A"}, Album: "First", PlayCount: 3, Period: "all_time", Provider: Subsonic},
+			},
+			calls: []string{"getAlbumList2", "getAlbum", "getAlbum"},
+		},
+		{
+			mode: SubsonicStarred, count: 5,
+			want: []Song{
+				{Title: "Three", Artists: []string{"B"}, Provider: Subsonic},
+				{Title: "One", Artists: []string{"A"}, Provider: Subsonic},
+			},
+			calls: []string{"getStarred2"},
+		},
+		// The share of the song 2 is reused, the song 1 only has a share with other songs
+		{
+			mode: SubsonicPlayed, share: true, count: 2,
+			want: []Song{
+				{Title: "Two", Artists: []string{"B"}, Album: "Second", PlayCount: 7, Period: "all_time", Provider: Subsonic, RecordingMbid: "mbid", Link: "https://music.test/share/2"},
+				{Title: "One", Artists: []string{"A"}, Album: "First", PlayCount: 3, Period: "all_time", Provider: Subsonic, Link: "https://music.test/share/new-1"},
+			},
+			calls: []string{"getAlbumList2", "getAlbum", "getAlbum", "getShares", "getShares", "createShare"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v share %v", test.mode, test.share), func(t *testing.T) {
+			fake := &fakeSubsonic{}
+			server := httptest.NewServer(fake.handler(t))
+			defer server.Close()
+
+			provider := SubsonicProvider{Url: server.URL + "/music/", Username: "user", Password: "password", Mode: test.mode, Share: test.share}
+			songs, err := provider.FetchList(context.Background(), test.count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(songs, test.want) {
+				t.Errorf("Got %+v, want %+v", songs, test.want)
+			}
+			if !reflect.DeepEqual(fake.calls, test.calls) {
+				t.Errorf("Called %v, want %v", fake.calls, test.calls)
+			}
+		})
+	}
+}
+
+func TestSubsonicErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		failing  bool
+		err      string
+	}{
+		{"wrong password", "wrong", false, "Wrong username or password"},
+		{"failing server", "password", true, "getAlbumList2 failed with the error 0: Broken"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeSubsonic{failing: test.failing}
+			server := httptest.NewServer(fake.handler(t))
+			defer server.Close()
+
+			provider := SubsonicProvider{Url: server.URL + "/music", Username: "user", Password: test.password, Mode: SubsonicPlayed}
+			_, err := provider.FetchList(context.Background(), 1)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Got the error %v, want one with %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const Youtube ProviderType = "youtube"
+
+type ScraperState uint8
+
+const (
+	LoadingChannel ScraperState = iota
+	DenyingCookies
+	ScrapingFavoriteMusic
+)
+
+func init() {
+	Register(Youtube, func() Provider { return &YoutubeProvider{} })
+}
+
+// Get the most listened music by scraping a youtube music channel
+type YoutubeProvider struct {
+	ChannelId string
+	UserAgent string
+}
+
+func (p *YoutubeProvider) Name() ProviderType {
+	return Youtube
+}
+
+func (p *YoutubeProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.ChannelId, "youtubeChannelId", "", "Youtube channel ID if you want to get the most listened music from a channel. They must have \"Enable public stats\" turned on.")
+	flags.StringVar(&p.UserAgent, "user-agent", "Mozilla/5.0 (X11; Linux x86_64; rv:140.0) Gecko/20100101 Firefox/140.0", "[DEPRECATED, use userAgent].")
+	flags.StringVar(&p.UserAgent, "userAgent", "Mozilla/5.0 (X11; Linux x86_64; rv:140.0) Gecko/20100101 Firefox/140.0", "User agent used while fetching the favorite music. Do not modify this if it already works.")
+}
+
+func (p *YoutubeProvider) Configured() bool {
+	return p.ChannelId != ""
+}
+
+func (p *YoutubeProvider) Validate() error {
+	if p.UserAgent == "" {
+		return errors.New("The userAgent flag cannot be empty when using youtube.")
+	}
+	return nil
+}
+
+// The channel id used to be given as the first argument, it still works but is deprecated
+func (p *YoutubeProvider) SetArgument(argument string) error {
+	if p.ChannelId != "" {
+		return errors.New("A youtube channel id was supplied by both --youtubeChannelId and the first argument, please use the --youtubeChannelId argument.")
+	}
+	fmt.Fprintln(os.Stderr, "[WARNING] A youtube channel id was provided using a normal argument, this has been deprecated, but will continue to function normally. Please use --youtubeChannelId from now on.")
+	p.ChannelId = argument
+	return nil
+}
+
+func (p *YoutubeProvider) Fetch(ctx context.Context) (song Song, err error) {
+	fmt.Println("Please make sure that \"Enable public stats\" is enabled in your youtube music channel settings.")
+	if deadline, ok := ctx.Deadline(); ok {
+		fmt.Printf("Currently fetching the favorite music, this might take a bit long... (Timeout of %v)\n", time.Until(deadline).Round(time.Second))
+	}
+	return GetFavoriteFromChannelId(ctx, p.ChannelId, p.UserAgent)
+}
+
+// Get the first favorite music from that youtube music channel
+// Expects that "Enable public stats" is enabled for the youtube channel, otherwise it won't work and will hit the timeout
+func GetFavoriteFromChannelId(ctx context.Context, channel_id string, user_agent string) (song Song, err error) {
+	var name, music_link, author string
+	// Set language to english since we expect to get the english youtube music
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("lang", "en"),
+		chromedp.Env("LANG=en"),
+		chromedp.UserAgent(user_agent),
+	)
+
+	// The timeout comes from the context that was given to us
+	actx, acancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer acancel()
+	bctx, bcancel := chromedp.NewContext(actx)
+	defer bcancel()
+
+	// Set the ok value to true to prevent the href error from overwriting the real one
+	href_ok := true
+	scraper_state := LoadingChannel
+	err = chromedp.Run(bctx,
+		// Reject all cookies when going to the website
+		chromedp.Navigate("https://music.youtube.com/channel/"+channel_id),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			scraper_state = DenyingCookies
+			return nil
+		}),
+		chromedp.Click(`[aria-label="Reject all"]`, chromedp.ByQuery),
+
+		// Get the name, link, and author of the first favorite music
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			scraper_state = ScrapingFavoriteMusic
+			return nil
+		}),
+		chromedp.Text(`div#contents.style-scope.ytmusic-shelf-renderer a`, &name, chromedp.NodeVisible),
+		chromedp.AttributeValue(`div#contents.style-scope.ytmusic-shelf-renderer a`, "href", &music_link, &href_ok, chromedp.NodeVisible),
+		chromedp.Text(`div#contents.style-scope.ytmusic-shelf-renderer .flex-column a`, &author, chromedp.NodeVisible),
+	)
+
+	// If the deadline has been reached, then print out a message explaining at what stage did it fail with a guideline
+	if errors.Is(err, context.DeadlineExceeded) {
+		switch scraper_state {
+		case LoadingChannel:
+			log.Print("Timeout triggered: Loading the channel took too long. Is youtube music even accessible? Or your internet speed too slow?")
+		case DenyingCookies:
+			log.Print("Timeout triggered: Coudln't click on \"Reject all\" in the cookie banner. Maybe youtube music updated their website and favorite_music_badge needs to be updated for the new website.")
+		case ScrapingFavoriteMusic:
+			log.Print("Timeout triggered: While getting the favorite music from the website, the website has finished loading. Did you enable \"Enable public stats\" in your youtube music channel settings?")
+		}
+		log.Print("Nonetheless, please retry running this script before reporting this as a bug if this is not a problem on your side.")
+	}
+
+	if href_ok == false {
+		err = errors.New("Attribute 'href' not found in the \"a\" tag.")
+	}
+
+	name = strings.ReplaceAll(name, "(Official Video)", "")
+	song = Song{
+		Title:    strings.TrimSpace(name),
+		Artists:  []string{author},
+		Provider: Youtube,
+		Link:     "https://youtube.com/" + music_link,
+	}
+	return
+}
@@ -0,0 +1,198 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const YoutubeTakeout ProviderType = "youtube-takeout"
+
+// Header of the entries coming from youtube music inside of the takeout
+const youtubeMusicHeader = "YouTube Music"
+
+func init() {
+	Register(YoutubeTakeout, func() Provider { return &YoutubeTakeoutProvider{} })
+}
+
+// Get the most played song from the youtube watch history of a Google Takeout export
+//
+// Unlike the youtube provider this doesn't scrape anything, so it also works inside of CICD.
+type YoutubeTakeoutProvider struct {
+	// Comma separated list of files, globs or directories
+	Files  string
+	Period string
+	Entity Entity
+}
+
+func (p *YoutubeTakeoutProvider) Name() ProviderType {
+	return YoutubeTakeout
+}
+
+func (p *YoutubeTakeoutProvider) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&p.Files, "youtubeTakeout", "", "watch-history.json or watch-history.html files from a Google Takeout export, separated by ','. Globs and directories containing the files can also be given.")
+	flags.StringVar(&p.Period, "youtubeTakeoutPeriod", PeriodOverall, "Period over which to retrieve the top song from the takeout (7day, 1month, 3month, 6month, 12month or overall), counted back from the latest play.")
+}
+
+func (p *YoutubeTakeoutProvider) Configured() bool {
+	return p.Files != ""
+}
+
+func (p *YoutubeTakeoutProvider) Validate() error {
+	return validateHistoryPeriod(p.Period)
+}
+
+func (p *YoutubeTakeoutProvider) Fetch(ctx context.Context) (song Song, err error) {
+	return firstSong(p.FetchList(ctx, 1))
+}
+
+func (p *YoutubeTakeoutProvider) FetchList(ctx context.Context, count int) (songs []Song, err error) {
+	files, err := expandHistoryFiles(p.Files, "watch-history.*")
+	if err != nil {
+		return
+	}
+
+	listens, err := ReadYoutubeTakeout(files)
+	if err != nil {
+		return
+	}
+	return topFromHistory(listens, p.Period, p.Entity, count)
+}
+
+func (p *YoutubeTakeoutProvider) SetEntity(entity Entity) error {
+	if entity == EntityAlbum {
+		return errors.New("The youtube takeout does not contain the albums, only the favorite track or artist can be used.")
+	}
+	p.Entity = entity
+	return nil
+}
+
+// One entry inside of watch-history.json
+type TakeoutEntry struct {
+	Header    string            `json:"header"`
+	Title     string            `json:"title"`
+	TitleUrl  string            `json:"titleUrl"`
+	Subtitles []TakeoutSubtitle `json:"subtitles"`
+	Time      string            `json:"time"`
+}
+
+type TakeoutSubtitle struct {
+	Name string `json:"name"`
+}
+
+// Read the youtube music plays of every file, the format is chosen from the extension (.json or .html)
+func ReadYoutubeTakeout(files []string) (listens []historyListen, err error) {
+	for _, file := range files {
+		var content []byte
+		content, err = os.ReadFile(file)
+		if err != nil {
+			return
+		}
+
+		var entries []TakeoutEntry
+		if strings.EqualFold(filepath.Ext(file), ".html") {
+			entries, err = parseTakeoutHtml(string(content))
+		} else {
+			err = json.Unmarshal(content, &entries)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("While parsing the takeout %v: %w", file, err)
+		}
+
+		for _, entry := range entries {
+			listen, ok := entry.listen()
+			if ok {
+				listens = append(listens, listen)
+			}
+		}
+	}
+	return
+}
+
+// Convert a takeout entry to a listen, returns false if it is not a youtube music play
+func (entry TakeoutEntry) listen() (listen historyListen, ok bool) {
+	if entry.Header != youtubeMusicHeader {
+		return
+	}
+
+	video_id := takeoutVideoId(entry.TitleUrl)
+	if video_id == "" {
+		return
+	}
+
+	listened_at, err := time.Parse(time.RFC3339, entry.Time)
+	if err != nil {
+		listened_at, err = parseTakeoutHtmlTime(entry.Time)
+		if err != nil {
+			return
+		}
+	}
+
+	song := Song{
+		Title:    strings.TrimPrefix(entry.Title, "Watched "),
+		Provider: YoutubeTakeout,
+		Link:     "https://music.youtube.com/watch?v=" + url.QueryEscape(video_id),
+	}
+	if len(entry.Subtitles) > 0 {
+		// Auto generated channels of the artists end with " - Topic"
+		song.Artists = []string{strings.TrimSuffix(entry.Subtitles[0].Name, " - Topic")}
+	}
+
+	return historyListen{Time: listened_at, Song: song, Key: video_id, Weight: 1, Played: true}, true
+}
+
+// Get the v parameter of a youtube watch link
+func takeoutVideoId(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("v")
+}
+
+var takeoutHtmlCell = regexp.MustCompile(`<div class="outer-cell`)
+var takeoutHtmlHeader = regexp.MustCompile(`<p class="mdl-typography--title">(.*?)<br`)
+var takeoutHtmlLink = regexp.MustCompile(`<a href="([^"]*)">(.*?)</a>`)
+var takeoutHtmlDate = regexp.MustCompile(`<br>([^<]*\d{4}[^<]*)</div>`)
+
+// Parse the entries of watch-history.html, only the fields that are used are filled
+func parseTakeoutHtml(content string) (entries []TakeoutEntry, err error) {
+	cells := takeoutHtmlCell.Split(content, -1)
+	for _, cell := range cells[1:] {
+		header := takeoutHtmlHeader.FindStringSubmatch(cell)
+		links := takeoutHtmlLink.FindAllStringSubmatch(cell, 2)
+		date := takeoutHtmlDate.FindStringSubmatch(cell)
+		if header == nil || len(links) == 0 || date == nil {
+			continue
+		}
+
+		entry := TakeoutEntry{
+			Header:   html.UnescapeString(strings.TrimSpace(header[1])),
+			Title:    html.UnescapeString(links[0][2]),
+			TitleUrl: html.UnescapeString(links[0][1]),
+			Time:     html.UnescapeString(strings.TrimSpace(date[1])),
+		}
+		if len(links) > 1 {
+			entry.Subtitles = []TakeoutSubtitle{{Name: html.UnescapeString(links[1][2])}}
+		}
+		entries = append(entries, entry)
+	}
+	return
+}
+
+// Parse the english date of watch-history.html like "Jan 2, 2006, 3:04:05 PM UTC"
+func parseTakeoutHtmlTime(date string) (time.Time, error) {
+	// Newer exports use a narrow no-break space before AM/PM
+	date = strings.ReplaceAll(date, "\u202f", " ")
+	date = strings.ReplaceAll(date, "\u00a0", " ")
+	return time.Parse("Jan 2, 2006, 3:04:05 PM MST", date)
+}
@@ -0,0 +1,120 @@
+package providers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Cell of watch-history.html, like the ones of the takeout
+func takeoutCell(header string, body string) string {
+	return `<div class="outer-cell mdl-cell mdl-cell--12-col mdl-shadow--2dp"><div class="mdl-grid">` +
+		`<div class="header-cell mdl-cell mdl-cell--12-col"><p class="mdl-typography--title">` + header + `<br></p></div>` +
+		`<div class="content-cell mdl-cell mdl-cell--6-col mdl-typography--body-1">` + body + `</div></div></div>`
+}
+
+func TestParseTakeoutHtml(t *testing.T) {
+	content := `<html><body><div class="mdl-grid">` +
+		takeoutCell("YouTube Music", `Watched&nbsp;<a href="https://music.youtube.com/watch?v=abc&amp;list=x">Song &amp; Dance</a><br><a href="https://www.youtube.com/channel/1">Artist - Topic</a><br>Mar 5, 2025, 8:15:00 PM UTC</div>`) +
+		takeoutCell("YouTube", `Watched <a href="https://www.youtube.com/watch?v=def">Video</a><br>Mar 4, 2025, 9:00:00 AM UTC</div>`) +
+		// Removed videos don't have a link
+		takeoutCell("YouTube Music", `Watched a video that has been removed<br>Mar 3, 2025, 9:00:00 AM UTC</div>`) +
+		`</div></body></html>`
+
+	entries, err := parseTakeoutHtml(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TakeoutEntry{
+		{Header: "YouTube Music", Title: "Song & Dance", TitleUrl: "https://music.youtube.com/watch?v=abc&list=x", Subtitles: []TakeoutSubtitle{{Name: "Artist - Topic"}}, Time: "Mar 5, 2025, 8:15:00 PM UTC"},
+		{Header: "YouTube", Title: "Video", TitleUrl: "https://www.youtube.com/watch?v=def", Time: "Mar 4, 2025, 9:00:00 AM UTC"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Got %+v, want %+v", entries, want)
+	}
+
+	if entries, err := parseTakeoutHtml("<html>nothing</html>"); err != nil || len(entries) != 0 {
+		t.Errorf("Got %v and %v for a page without entries", entries, err)
+	}
+}
+
+func TestParseTakeoutHtmlTime(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{"Mar 5, 2025, 8:15:00 PM UTC", time.Date(2025, time.March, 5, 20, 15, 0, 0, time.UTC)},
+		// Newer exports have a narrow no-break space before AM or PM
+		{"Mar 5, 2025, 8:15:00\u202fAM UTC", time.Date(2025, time.March, 5, 8, 15, 0, 0, time.UTC)},
+		{"Dec 31, 2024, 11:59:59 PM UTC", time.Date(2024, time.December, 31, 23, 59, 59, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		date, err := parseTakeoutHtmlTime(test.date)
+		if err != nil {
+			t.Errorf("%q: %v", test.date, err)
+			continue
+		}
+		if !date.Equal(test.want) {
+			t.Errorf("%q: got %v, want %v", test.date, date, test.want)
+		}
+	}
+	if _, err := parseTakeoutHtmlTime("5 mars 2025, 20:15:00 UTC"); err == nil {
+		t.Error("No error for a date that isn't in english")
+	}
+}
+
+func TestTakeoutEntryListen(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry TakeoutEntry
+		ok    bool
+		want  historyListen
+	}{
+		{
+			name:  "json entry",
+			entry: TakeoutEntry{Header: "YouTube Music", Title: "Watched Song", TitleUrl: "https://music.youtube.com/watch?v=abc", Subtitles: []TakeoutSubtitle{{Name: "Artist - Topic"}}, Time: "2025-03-05T20:15:00.123Z"},
+			ok:    true,
+			want: historyListen{
+				Time:   time.Date(2025, time.March, 5, 20, 15, 0, 123000000, time.UTC),
+				Song:   Song{Title: "Song", Artists: []string{"Artist"}, Provider: YoutubeTakeout, Link: "https://music.youtube.com/watch?v=abc"},
+				Key:    "abc",
+				Weight: 1,
+				Played: true,
+			},
+		},
+		{
+			name:  "html entry without artist",
+			entry: TakeoutEntry{Header: "YouTube Music", Title: "Song", TitleUrl: "https://music.youtube.com/watch?v=abc", Time: "Mar 5, 2025, 8:15:00 PM UTC"},
+			ok:    true,
+			want: historyListen{
+				Time:   time.Date(2025, time.March, 5, 20, 15, 0, 0, time.UTC),
+				Song:   Song{Title: "Song", Provider: YoutubeTakeout, Link: "https://music.youtube.com/watch?v=abc"},
+				Key:    "abc",
+				Weight: 1,
+				Played: true,
+			},
+		},
+		{
+			name:  "youtube video",
+			entry: TakeoutEntry{Header: "YouTube", Title: "Watched Video", TitleUrl: "https://www.youtube.com/watch?v=def", Time: "2025-03-05T20:15:00Z"},
+		},
+		{
+			name:  "no video id",
+			entry: TakeoutEntry{Header: "YouTube Music", Title: "Watched Song", TitleUrl: "https://music.youtube.com/", Time: "2025-03-05T20:15:00Z"},
+		},
+		{
+			name:  "invalid time",
+			entry: TakeoutEntry{Header: "YouTube Music", Title: "Watched Song", TitleUrl: "https://music.youtube.com/watch?v=abc", Time: "yesterday"},
+		},
+	}
+	for _, test := range tests {
+		listen, ok := test.entry.listen()
+		if ok != test.ok {
+			t.Errorf("%v: got %v, want %v", test.name, ok, test.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(listen, test.want) {
+			t.Errorf("%v: got %+v, want %+v", test.name, listen, test.want)
+		}
+	}
+}
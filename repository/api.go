@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/forge"
+)
+
+// Update the badge with the "contents" API of the forge, without cloning anything
+//
+// Only the token of options.Forge is needed, no SSH key. Every file is updated in its own commit with
+// the sha that was read, so the update fails instead of overwriting a change made in the meantime.
+// The badge files are updated before the file, so that it never links to a badge that doesn't exist.
+func UpdateWithForgeApi(ctx context.Context, repository string, filename string, contents map[string]string, badge_files map[string][]byte, options Options) (err error) {
+	api, err := forge.New(options.Forge, repository)
+	if err != nil {
+		return
+	}
+
+	shas := map[string]string{}
+	read := func(path string) (content []byte, err error) {
+		file, err := api.GetFile(ctx, path, options.Branch)
+		if errors.Is(err, forge.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+		}
+		if err != nil {
+			return
+		}
+		shas[path] = file.Sha
+		return file.Content, nil
+	}
+
+	// The API always uses slashes
+	slash_badge_files := map[string][]byte{}
+	for badge_filename, content := range badge_files {
+		slash_badge_files[filepath.ToSlash(badge_filename)] = content
+	}
+	changes, err := badgeChanges(read, filepath.ToSlash(filename), contents, slash_badge_files)
+	if err != nil {
+		return
+	}
+	if options.DryRun {
+		return dryRun(changes)
+	}
+
+	updated := false
+	for _, c := range append(slices.Clone(changes[1:]), changes[0]) {
+		if !c.changed() {
+			continue
+		}
+		err = api.UpdateFile(ctx, options.fileUpdate(c.path, c.after, shas[c.path]))
+		if err != nil {
+			return fmt.Errorf("While updating %v: %w", c.path, err)
+		}
+		fmt.Printf("Updated %v\n", c.path)
+		updated = true
+	}
+	if !updated {
+		fmt.Println("Nothing has changed, same favorite music. Not trying to update repository.")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/forge"
+)
+
+// Fake Forgejo contents API, returns the files and the paths that were updated in order
+func fakeForgeApi(t *testing.T, files map[string]string) (server *httptest.Server, updated *[]string) {
+	updated = &[]string{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, found := strings.CutPrefix(r.URL.Path, "/api/v1/repos/owner/name/contents/")
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			content, ok := files[path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(content)), "sha": "sha-" + path})
+			return
+		}
+
+		var body struct {
+			Content string `json:"content"`
+			Sha     string `json:"sha"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		if _, exists := files[path]; exists && body.Sha != "sha-"+path {
+			http.Error(w, "wrong sha", http.StatusConflict)
+			return
+		}
+		if body.Message != COMMIT_MESSAGE {
+			t.Errorf("Got the commit message %q", body.Message)
+		}
+		content, _ := base64.StdEncoding.DecodeString(body.Content)
+		files[path] = string(content)
+		*updated = append(*updated, path)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+	return
+}
+
+func TestUpdateWithForgeApi(t *testing.T) {
+	files := map[string]string{"docs/README.md": TEST_README}
+	server, updated := fakeForgeApi(t, files)
+	options := Options{Forge: forge.Options{Type: string(forge.Forgejo), Url: server.URL + "/api/v1", Token: "token", Project: "owner/name"}}
+	badge_files := map[string][]byte{"docs/badge.svg": []byte("<svg></svg>")}
+	contents := map[string]string{"": "new"}
+
+	// Nothing is written with a dry run
+	options.DryRun = true
+	err := UpdateWithForgeApi(context.Background(), "", "docs/README.md", contents, badge_files, options)
+	if !errors.Is(err, ErrWouldChange) || len(*updated) != 0 {
+		t.Fatalf("Got %v and the updates %v, want ErrWouldChange without update", err, *updated)
+	}
+
+	// The badge is updated before the file that links to it
+	options.DryRun = false
+	err = UpdateWithForgeApi(context.Background(), "", "docs/README.md", contents, badge_files, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(*updated, " ") != "docs/badge.svg docs/README.md" {
+		t.Errorf("Updated %v, want the badge then the file", *updated)
+	}
+	if !strings.Contains(files["docs/README.md"], "-->\nnew\n<!--") {
+		t.Errorf("The badge wasn't added: %q", files["docs/README.md"])
+	}
+
+	// Nothing has changed, nothing is updated
+	*updated = nil
+	err = UpdateWithForgeApi(context.Background(), "", "docs/README.md", contents, badge_files, options)
+	if err != nil || len(*updated) != 0 {
+		t.Errorf("Got %v and the updates %v without any change", err, *updated)
+	}
+
+	// A file without the markers isn't touched
+	files["docs/README.md"] = "no markers\n"
+	err = UpdateWithForgeApi(context.Background(), "", "docs/README.md", map[string]string{"": "newer"}, badge_files, options)
+	if err == nil || len(*updated) != 0 {
+		t.Errorf("Got %v and the updates %v for a file without markers", err, *updated)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/forge"
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Placeholders of the commit message, replaced by the favorite song (the first one of the first block)
+const (
+	SONG_PLACEHOLDER     = "{song}"
+	ARTIST_PLACEHOLDER   = "{artist}"
+	ALBUM_PLACEHOLDER    = "{album}"
+	PROVIDER_PLACEHOLDER = "{provider}"
+)
+
+// Replace the placeholders of the commit message by the song
+func (options *Options) FillCommitMessage(song providers.Song) {
+	options.CommitMessage = strings.NewReplacer(
+		SONG_PLACEHOLDER, song.Title,
+		ARTIST_PLACEHOLDER, song.Author(),
+		ALBUM_PLACEHOLDER, song.Album,
+		PROVIDER_PLACEHOLDER, string(song.Provider),
+	).Replace(options.CommitMessage)
+}
+
+// Full commit message, the trailers are added after an empty line
+func (options Options) commitMessage() string {
+	message := options.CommitMessage
+	if message == "" {
+		message = COMMIT_MESSAGE
+	}
+
+	var trailers []string
+	for trailer := range strings.SplitSeq(options.Trailers, "\n") {
+		if trailer = strings.TrimSpace(trailer); trailer != "" {
+			trailers = append(trailers, trailer)
+		}
+	}
+	if len(trailers) == 0 {
+		return message
+	}
+	return message + "\n\n" + strings.Join(trailers, "\n")
+}
+
+// First line of the commit message, used as the title of the pull request
+func (options Options) commitTitle() string {
+	title, _, _ := strings.Cut(options.commitMessage(), "\n")
+	return title
+}
+
+// Options of the commit, the author and committer are taken from the git config when they are not set
+//
+// The commit is signed when signer isn't nil.
+func (options Options) commitOptions(signer git.Signer) *git.CommitOptions {
+	commit_options := git.CommitOptions{Signer: signer}
+	now := time.Now()
+	if options.AuthorName != "" {
+		commit_options.Author = &object.Signature{Name: options.AuthorName, Email: options.AuthorEmail, When: now}
+	}
+	// go-git uses the author as the committer by default
+	if options.CommitterName != "" {
+		commit_options.Committer = &object.Signature{Name: options.CommitterName, Email: options.CommitterEmail, When: now}
+	}
+	return &commit_options
+}
+
+// Update of a file with the API of the forge, the forge chooses the author and committer when they are not set
+func (options Options) fileUpdate(path string, content []byte, sha string) forge.FileUpdate {
+	return forge.FileUpdate{
+		Path:      path,
+		Branch:    options.Branch,
+		Content:   content,
+		Sha:       sha,
+		Message:   options.commitMessage(),
+		Author:    forge.Identity{Name: options.AuthorName, Email: options.AuthorEmail},
+		Committer: forge.Identity{Name: options.CommitterName, Email: options.CommitterEmail},
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"testing"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/providers"
+)
+
+func TestFillCommitMessage(t *testing.T) {
+	song := providers.Song{Title: "One", Artists: []string{"A", "B"}, Album: "First", Provider: providers.LastFm}
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"{song} by {artist} from {album} on {provider}", "One by A, B from First on lastfm"},
+		// Every placeholder is replaced, not only the first one
+		{"{song} ({song})", "One (One)"},
+		{"feat: updated favorite_music_badge", "feat: updated favorite_music_badge"},
+		{"{unknown} {Song}", "{unknown} {Song}"},
+	}
+	for _, test := range tests {
+		options := Options{CommitMessage: test.message}
+		options.FillCommitMessage(song)
+		if options.CommitMessage != test.want {
+			t.Errorf("%q: got %q, want %q", test.message, options.CommitMessage, test.want)
+		}
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		trailers string
+		want     string
+	}{
+		{"default message", "", "", COMMIT_MESSAGE},
+		{"without trailers", "Title\n\nBody", "", "Title\n\nBody"},
+		{"trailers", "Title", "[skip ci]\nSigned-off-by: A <a@example.com>", "Title\n\n[skip ci]\nSigned-off-by: A <a@example.com>"},
+		// A trailer can contain commas, the empty lines are skipped
+		{"trailer with a comma", "Title", "\nCo-authored-by: A, B <a@example.com>\n\n", "Title\n\nCo-authored-by: A, B <a@example.com>"},
+	}
+	for _, test := range tests {
+		options := Options{CommitMessage: test.message, Trailers: test.trailers}
+		if message := options.commitMessage(); message != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, message, test.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// A file of the repository and the content it gets
+type change struct {
+	// Path relative to the directory of the repository
+	path string
+	// Content before the change, nil if the file doesn't exist yet
+	before []byte
+	after  []byte
+}
+
+func (c change) changed() bool {
+	return c.before == nil || !bytes.Equal(c.before, c.after)
+}
+
+// Write the changes as a unified diff (like git diff), returns whether something would change
+func writeDiff(w io.Writer, changes []change) (changed bool, err error) {
+	var patch diffPatch
+	for _, c := range changes {
+		if !c.changed() {
+			continue
+		}
+		patch = append(patch, newFilePatch(c))
+	}
+	if len(patch) == 0 {
+		return false, nil
+	}
+	return true, fdiff.NewUnifiedEncoder(w, fdiff.DefaultContextLines).Encode(patch)
+}
+
+// Implementation of the diff interfaces of go-git for files that are not committed yet
+type diffPatch []fdiff.FilePatch
+
+func (p diffPatch) FilePatches() []fdiff.FilePatch { return p }
+func (p diffPatch) Message() string                { return "" }
+
+type diffFile struct {
+	path    string
+	content []byte
+}
+
+func (f diffFile) Hash() plumbing.Hash {
+	return plumbing.ComputeHash(plumbing.BlobObject, f.content)
+}
+func (f diffFile) Mode() filemode.FileMode { return filemode.Regular }
+func (f diffFile) Path() string            { return f.path }
+
+type diffChunk struct {
+	content   string
+	operation fdiff.Operation
+}
+
+func (c diffChunk) Content() string       { return c.content }
+func (c diffChunk) Type() fdiff.Operation { return c.operation }
+
+type filePatch struct {
+	from   fdiff.File
+	to     fdiff.File
+	chunks []fdiff.Chunk
+}
+
+func newFilePatch(c change) (patch filePatch) {
+	// from stays a nil interface for new files, so that the diff shows "new file"
+	if c.before != nil {
+		patch.from = diffFile{c.path, c.before}
+	}
+	patch.to = diffFile{c.path, c.after}
+
+	for _, d := range diff.Do(string(c.before), string(c.after)) {
+		operation := fdiff.Equal
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			operation = fdiff.Delete
+		case diffmatchpatch.DiffInsert:
+			operation = fdiff.Add
+		}
+		patch.chunks = append(patch.chunks, diffChunk{d.Text, operation})
+	}
+	return
+}
+
+func (p filePatch) IsBinary() bool               { return false }
+func (p filePatch) Files() (from, to fdiff.File) { return p.from, p.to }
+func (p filePatch) Chunks() []fdiff.Chunk        { return p.chunks }
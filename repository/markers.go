@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Markers searched inside of the file, they are usually put inside of html comments
+// (<!-- FAVORITE_MUSIC_BADGE:START -->) so that they are not shown
+const (
+	// Everything between the start and end markers is regenerated, the start marker can have an id
+	// (FAVORITE_MUSIC_BADGE:START id=weekly) to have several blocks inside of the same file
+	START_MARKER = "FAVORITE_MUSIC_BADGE:START"
+	END_MARKER   = "FAVORITE_MUSIC_BADGE:END"
+	// Only the line after this marker is regenerated, kept for backward compatibility
+	LEGACY_MARKER = "FAVORITE_MUSIC_BADGE_AFTER_THIS_LINE"
+)
+
+var startMarkerId = regexp.MustCompile(regexp.QuoteMeta(START_MARKER) + `(?:\s+id=([^\s"'<>]+))?`)
+
+// Replace the blocks inside of the text of a file, contents maps the id of a block to its content
+//
+// The empty id is used for the markers without an id and for the LEGACY_MARKER. Everything between
+// the START_MARKER and END_MARKER lines is replaced, the markers themselves are kept. With the
+// LEGACY_MARKER, only the line after it is replaced, so content should be a single line.
+//
+// Blocks without a content are kept as they are, but every content must have a block. The lines that
+// are added use the line ending of the file (\r\n or \n).
+func ReplaceBlocks(text string, contents map[string]string) (replaced string, err error) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	// The kept lines keep their \r, only the added lines need it
+	carriage_return := ""
+	if strings.Contains(text, "\r\n") {
+		carriage_return = "\r"
+	}
+	// Split the content into lines with the line ending of the file
+	content_lines := func(content string) []string {
+		added := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+		for i := range added {
+			added[i] += carriage_return
+		}
+		return added
+	}
+
+	var output []string
+	var found []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		output = append(output, line)
+
+		if match := startMarkerId.FindStringSubmatch(line); match != nil {
+			if strings.Contains(line, END_MARKER) {
+				return "", fmt.Errorf("The %v and %v markers on line %v must be on their own lines.", START_MARKER, END_MARKER, i+1)
+			}
+			id := match[1]
+			end := i + 1
+			for end < len(lines) && !strings.Contains(lines[end], END_MARKER) {
+				if strings.Contains(lines[end], START_MARKER) {
+					return "", fmt.Errorf("The %v marker on line %v is not closed by a %v marker before the next one.", START_MARKER, i+1, END_MARKER)
+				}
+				end++
+			}
+			if end == len(lines) {
+				return "", fmt.Errorf("The %v marker on line %v is never closed by a %v marker.", START_MARKER, i+1, END_MARKER)
+			}
+
+			content, ok := contents[id]
+			if !ok {
+				// Not ours to regenerate
+				continue
+			}
+			output = append(output, content_lines(content)...)
+			output = append(output, lines[end])
+			i = end
+			found = append(found, id)
+		} else if strings.Contains(line, LEGACY_MARKER) {
+			content, ok := contents[""]
+			if !ok {
+				continue
+			}
+			output = append(output, content_lines(content)...)
+			// Skip the line that was replaced
+			i++
+			found = append(found, "")
+		}
+	}
+
+	for id := range contents {
+		if slices.Contains(found, id) {
+			continue
+		}
+		if id == "" {
+			return "", errors.New("Tried to add a favorite music badge without a FAVORITE_MUSIC_BADGE:START and FAVORITE_MUSIC_BADGE:END (or FAVORITE_MUSIC_BADGE_AFTER_THIS_LINE) inside of the file")
+		}
+		return "", fmt.Errorf("Tried to fill the block \"%v\" without a FAVORITE_MUSIC_BADGE:START id=%v marker inside of the file", id, id)
+	}
+
+	// A file without a line ending at the end gets one
+	if last := len(output) - 1; !strings.HasSuffix(output[last], carriage_return) {
+		output[last] += carriage_return
+	}
+	return strings.Join(output, "\n") + "\n", nil
+}
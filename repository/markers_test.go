@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplaceBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		contents map[string]string
+		want     string
+		// Part of the error, empty when there is none
+		err string
+	}{
+		{
+			name:     "start and end markers",
+			text:     "hi\n<!-- FAVORITE_MUSIC_BADGE:START -->\nold\nolder\n<!-- FAVORITE_MUSIC_BADGE:END -->\nbye\n",
+			contents: map[string]string{"": "new"},
+			want:     "hi\n<!-- FAVORITE_MUSIC_BADGE:START -->\nnew\n<!-- FAVORITE_MUSIC_BADGE:END -->\nbye\n",
+		},
+		{
+			name:     "empty block",
+			text:     "<!-- FAVORITE_MUSIC_BADGE:START -->\n<!-- FAVORITE_MUSIC_BADGE:END -->",
+			contents: map[string]string{"": "new"},
+			want:     "<!-- FAVORITE_MUSIC_BADGE:START -->\nnew\n<!-- FAVORITE_MUSIC_BADGE:END -->\n",
+		},
+		{
+			name:     "legacy marker",
+			text:     "<!-- FAVORITE_MUSIC_BADGE_AFTER_THIS_LINE -->\nold\nkept\n",
+			contents: map[string]string{"": "new"},
+			want:     "<!-- FAVORITE_MUSIC_BADGE_AFTER_THIS_LINE -->\nnew\nkept\n",
+		},
+		{
+			name: "named blocks",
+			text: "<!-- FAVORITE_MUSIC_BADGE:START id=weekly -->\na\n<!-- FAVORITE_MUSIC_BADGE:END -->\n" +
+				"<!-- FAVORITE_MUSIC_BADGE:START id=other -->\nkept\n<!-- FAVORITE_MUSIC_BADGE:END -->\n",
+			contents: map[string]string{"weekly": "b"},
+			want: "<!-- FAVORITE_MUSIC_BADGE:START id=weekly -->\nb\n<!-- FAVORITE_MUSIC_BADGE:END -->\n" +
+				"<!-- FAVORITE_MUSIC_BADGE:START id=other -->\nkept\n<!-- FAVORITE_MUSIC_BADGE:END -->\n",
+		},
+		{
+			name:     "multi line content",
+			text:     "<!-- FAVORITE_MUSIC_BADGE:START -->\n<!-- FAVORITE_MUSIC_BADGE:END -->\n",
+			contents: map[string]string{"": "one\ntwo"},
+			want:     "<!-- FAVORITE_MUSIC_BADGE:START -->\none\ntwo\n<!-- FAVORITE_MUSIC_BADGE:END -->\n",
+		},
+		{
+			name:     "crlf",
+			text:     "hi\r\n<!-- FAVORITE_MUSIC_BADGE:START -->\r\nold\r\n<!-- FAVORITE_MUSIC_BADGE:END -->\r\nbye\r\n",
+			contents: map[string]string{"": "one\ntwo"},
+			want:     "hi\r\n<!-- FAVORITE_MUSIC_BADGE:START -->\r\none\r\ntwo\r\n<!-- FAVORITE_MUSIC_BADGE:END -->\r\nbye\r\n",
+		},
+		{
+			name:     "crlf legacy marker without a last line ending",
+			text:     "<!-- FAVORITE_MUSIC_BADGE_AFTER_THIS_LINE -->\r\nold",
+			contents: map[string]string{"": "new"},
+			want:     "<!-- FAVORITE_MUSIC_BADGE_AFTER_THIS_LINE -->\r\nnew\r\n",
+		},
+		{
+			name:     "start and end on the same line",
+			text:     "<!-- FAVORITE_MUSIC_BADGE:START --><!-- FAVORITE_MUSIC_BADGE:END -->\n",
+			contents: map[string]string{"": "new"},
+			err:      "must be on their own lines",
+		},
+		{
+			name:     "never closed",
+			text:     "<!-- FAVORITE_MUSIC_BADGE:START -->\nold\n",
+			contents: map[string]string{"": "new"},
+			err:      "never closed",
+		},
+		{
+			name:     "nested",
+			text:     "<!-- FAVORITE_MUSIC_BADGE:START -->\n<!-- FAVORITE_MUSIC_BADGE:START id=a -->\n<!-- FAVORITE_MUSIC_BADGE:END -->\n",
+			contents: map[string]string{"": "new"},
+			err:      "before the next one",
+		},
+		{
+			name:     "missing marker",
+			text:     "nothing\n",
+			contents: map[string]string{"": "new"},
+			err:      "without a FAVORITE_MUSIC_BADGE:START",
+		},
+		{
+			name:     "missing named block",
+			text:     "<!-- FAVORITE_MUSIC_BADGE:START -->\n<!-- FAVORITE_MUSIC_BADGE:END -->\n",
+			contents: map[string]string{"weekly": "new"},
+			err:      `block "weekly"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			replaced, err := ReplaceBlocks(test.text, test.contents)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Got the error %v, want one with %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replaced != test.want {
+				t.Errorf("Got %q, want %q", replaced, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+// Publish the favorite music badge to a git repository
+//
+// Git is used through go-git, so the git binary doesn't need to be installed (only local
+// repositories, /path/to/repo.git or file://, are cloned and pushed with git-upload-pack and git-receive-pack).
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"codeberg.org/virtualfuzz/favorite_music_badge/forge"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// Default message of the commit that updates the badge, its first line is also the title of the pull request
+const COMMIT_MESSAGE = "feat: updated favorite_music_badge"
+
+// Description of the pull request
+const PULL_REQUEST_BODY = "Updated the favorite music badge, opened by [favorite_music_badge](https://codeberg.org/virtualfuzz/favorite_music_badge)."
+
+// How the repository is updated
+type Options struct {
+	// Keep the clone after the run instead of removing it, for debugging
+	KeepClone bool
+	// Commit the change when updating a local working tree, changes are always committed
+	// (and pushed) when the repository is cloned
+	Commit bool
+	// Only print the diff of the file, nothing is written, committed or pushed
+	DryRun bool
+	// Branch to update, the default branch when empty
+	Branch string
+	// Message of the commit, can have placeholders (see FillCommitMessage)
+	CommitMessage string
+	// Lines added at the end of the commit message ([skip ci], Signed-off-by: ...), one per line
+	Trailers string
+	// Identity of the commit, taken from the git config (user.name and user.email) when empty
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+	// OpenPGP or SSH private key that signs the commits, either read from the file or given directly
+	SigningKeyFile       string
+	SigningKey           string
+	SigningKeyPassphrase string
+	// How many times the substitution is done again on top of the new commits of the branch when
+	// the push is rejected because someone else pushed
+	PushRetries int
+	// Push to this branch and open a pull request to the default branch instead of pushing to it,
+	// the branch is overwritten on every run so it should only be used by favorite_music_badge
+	PullRequestBranch string
+	// Update the file with the API of the forge instead of cloning the repository (see UpdateWithForgeApi)
+	ForgeApi bool
+	// API of the forge used to open the pull request or to update the file, its token is also used
+	// to clone and push https repositories
+	Forge forge.Options
+}
+
+// Returned with Options.DryRun when the file would have been changed
+var ErrWouldChange = errors.New("The favorite music badge would be changed.")
+
+// Function to download a git repository and push the new badge to it
+//
+// contents maps the id of the marker blocks to the markdown that replaces them (see ReplaceBlocks).
+// badge_files are written inside of the repository and committed with the file, the key is
+// the path relative to the root of the repository (used for the svg badge).
+//
+// Only the latest commit of the branch (the default one if options.Branch is empty) is cloned, inside of a new temporary directory that is
+// removed once we are done (even on errors, or when ctx is cancelled). SSH repositories use the
+// ssh-agent and the known_hosts file, https repositories can have the credentials inside of the url
+// or use the token of options.Forge. When someone else pushed to the branch in the meantime, the
+// repository is cloned again and the badge is added on top of their commits, up to options.PushRetries times.
+//
+// With options.PullRequestBranch, the commit is pushed to that branch and a pull request is opened,
+// or the one that is already open is reused.
+func AddImageToRepository(ctx context.Context, repository string, filename string, contents map[string]string, badge_files map[string][]byte, options Options) (err error) {
+	// Check the forge and the signing key before cloning anything
+	var pull_request_forge forge.Forge
+	if options.PullRequestBranch != "" {
+		pull_request_forge, err = forge.New(options.Forge, repository)
+		if err != nil {
+			return
+		}
+	}
+	signer, err := options.signer()
+	if err != nil {
+		return
+	}
+
+	endpoint, err := transport.NewEndpoint(repository)
+	if err != nil {
+		return
+	}
+	clone_options := git.CloneOptions{
+		URL:          repository,
+		Auth:         tokenAuth(endpoint, options.Forge.Token),
+		Depth:        1,
+		SingleBranch: true,
+		Progress:     os.Stdout,
+	}
+	if options.Branch != "" {
+		clone_options.ReferenceName = plumbing.NewBranchReferenceName(options.Branch)
+	}
+
+	var head plumbing.ReferenceName
+	var changed bool
+	for attempt := 1; ; attempt++ {
+		head, changed, err = cloneAndPush(ctx, filename, contents, badge_files, options, clone_options, signer)
+		if !errors.Is(err, errPushRejected) || attempt > options.PushRetries {
+			break
+		}
+
+		// Someone pushed since the clone, start again from their commit
+		delay := retryDelay(attempt)
+		fmt.Printf("The push was rejected because the branch has changed, retrying on top of it in %v (%v/%v)\n", delay, attempt, options.PushRetries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	if err != nil || !changed {
+		return
+	}
+
+	if pull_request_forge == nil {
+		return nil
+	}
+	link, existed, err := pull_request_forge.OpenPullRequest(ctx, forge.PullRequest{
+		Head:  options.PullRequestBranch,
+		Base:  head.Short(),
+		Title: options.commitTitle(),
+		Body:  PULL_REQUEST_BODY,
+	})
+	if err != nil {
+		return fmt.Errorf("While opening the pull request: %w", err)
+	}
+	if existed {
+		fmt.Printf("Updated the pull request %v\n", link)
+	} else {
+		fmt.Printf("Opened the pull request %v\n", link)
+	}
+	return nil
+}
+
+// Clone the repository inside of a new temporary directory, add the badge to it, then commit and push it
+//
+// changed is false when the badge is already up to date, nothing is pushed then. The error wraps
+// errPushRejected when someone else pushed to the branch since the clone. The directory is removed
+// at the end unless options.KeepClone is set.
+func cloneAndPush(ctx context.Context, filename string, contents map[string]string, badge_files map[string][]byte, options Options, clone_options git.CloneOptions, signer git.Signer) (head plumbing.ReferenceName, changed bool, err error) {
+	directory, err := os.MkdirTemp("", "favorite_music_badge-")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if options.KeepClone {
+			fmt.Printf("Kept the clone of the repository inside of %v\n", directory)
+			return
+		}
+		if remove_err := os.RemoveAll(directory); remove_err != nil && err == nil {
+			err = remove_err
+		}
+	}()
+
+	repo, err := git.PlainCloneContext(ctx, directory, false, &clone_options)
+	if err != nil {
+		err = fmt.Errorf("While cloning %v: %w", clone_options.URL, err)
+		return
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return
+	}
+	reference, err := repo.Head()
+	if err != nil {
+		return
+	}
+	head = reference.Name()
+
+	changes, err := badgeChanges(readFrom(directory), filename, contents, badge_files)
+	if err != nil {
+		return
+	}
+	if options.DryRun {
+		err = dryRun(changes)
+		return
+	}
+	paths, err := writeChanges(directory, changes)
+	if err != nil {
+		return
+	}
+
+	changed, err = addToIndex(worktree, paths)
+	if err != nil {
+		return
+	}
+	if !changed {
+		fmt.Println("Nothing has changed, same favorite music. Not trying to update repository.")
+		return
+	}
+
+	// Files have been changed, do a git commit
+	hash, err := worktree.Commit(options.commitMessage(), options.commitOptions(signer))
+	if err != nil {
+		return
+	}
+	fmt.Printf("Created the commit %v\n", hash)
+
+	// Git push the commit
+	push_options := git.PushOptions{Auth: clone_options.Auth, Progress: os.Stdout}
+	if options.PullRequestBranch != "" {
+		// Force push, the branch only has the latest badge on top of the default branch
+		push_options.RefSpecs = []gitconfig.RefSpec{
+			gitconfig.RefSpec(fmt.Sprintf("+%v:refs/heads/%v", head, options.PullRequestBranch)),
+		}
+	}
+	beforePush()
+	err = repo.PushContext(ctx, &push_options)
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		err = nil
+	}
+	if err != nil && isPushRejected(err) {
+		err = fmt.Errorf("%w %w", errPushRejected, err)
+	}
+	if err != nil {
+		err = fmt.Errorf("While pushing to %v: %w", clone_options.URL, err)
+	}
+	return
+}
+
+// Authentication with the token for http and https repositories, nil when there is nothing to do
+//
+// Credentials inside of the url are kept. The username is ignored by GitHub and Forgejo,
+// GitLab needs oauth2.
+func tokenAuth(endpoint *transport.Endpoint, token string) transport.AuthMethod {
+	if token == "" || endpoint.User != "" || (endpoint.Protocol != "http" && endpoint.Protocol != "https") {
+		return nil
+	}
+	return &githttp.BasicAuth{Username: "oauth2", Password: token}
+}
+
+// Update the badge inside of a working tree that is already on the disk, without cloning or pushing
+//
+// This is useful inside of CICD where the repository is already checked out, the CICD can then push
+// the change itself. The change is only committed if options.Commit is true, the git repository is
+// then searched from the directory and its parents.
+func UpdateLocalRepository(directory string, filename string, contents map[string]string, badge_files map[string][]byte, options Options) (err error) {
+	// Check the signing key before changing anything
+	signer, err := options.signer()
+	if err != nil {
+		return
+	}
+
+	changes, err := badgeChanges(readFrom(directory), filename, contents, badge_files)
+	if err != nil {
+		return
+	}
+	if options.DryRun {
+		return dryRun(changes)
+	}
+	paths, err := writeChanges(directory, changes)
+	if err != nil {
+		return
+	}
+	if !options.Commit {
+		fmt.Printf("Updated %v without committing\n", filepath.Join(directory, filename))
+		return nil
+	}
+
+	repo, err := git.PlainOpenWithOptions(directory, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("While opening the git repository of %v: %w", directory, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return
+	}
+
+	// The paths are relative to the directory, the index wants them relative to the root of the working tree
+	absolute_directory, err := filepath.Abs(directory)
+	if err != nil {
+		return
+	}
+	for i := range paths {
+		var relative string
+		relative, err = filepath.Rel(worktree.Filesystem.Root(), filepath.Join(absolute_directory, paths[i]))
+		if err != nil {
+			return
+		}
+		paths[i] = relative
+	}
+
+	changed, err := addToIndex(worktree, paths)
+	if err != nil {
+		return
+	}
+	if !changed {
+		fmt.Println("Nothing has changed, same favorite music. Not creating a commit.")
+		return nil
+	}
+
+	hash, err := worktree.Commit(options.commitMessage(), options.commitOptions(signer))
+	if err != nil {
+		return
+	}
+	fmt.Printf("Created the commit %v\n", hash)
+	return nil
+}
+
+// Read a file of the repository, the error is fs.ErrNotExist if it doesn't exist
+type readFunc func(path string) (content []byte, err error)
+
+// Read the files from the directory
+func readFrom(directory string) readFunc {
+	return func(path string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(directory, path))
+	}
+}
+
+// Replace the markers of the file and add the badge files, the paths are relative to the repository
+//
+// Nothing is written, see writeChanges.
+func badgeChanges(read readFunc, filename string, contents map[string]string, badge_files map[string][]byte) (changes []change, err error) {
+	// Search the markers inside of the file and add the music badge
+	text, err := read(filename)
+	if err != nil {
+		return
+	}
+
+	replaced, err := ReplaceBlocks(string(text), contents)
+	if err != nil {
+		return
+	}
+	changes = append(changes, change{path: filename, before: text, after: []byte(replaced)})
+
+	// The other badge files are next to the file, they might not exist yet
+	for _, badge_filename := range slices.Sorted(maps.Keys(badge_files)) {
+		before, read_err := read(badge_filename)
+		if read_err != nil && !errors.Is(read_err, fs.ErrNotExist) {
+			return nil, read_err
+		}
+		changes = append(changes, change{path: badge_filename, before: before, after: badge_files[badge_filename]})
+	}
+	return
+}
+
+// Write the changes inside of the directory, returns the paths of every written file
+func writeChanges(directory string, changes []change) (paths []string, err error) {
+	for _, c := range changes {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(directory, c.path)), 0755)
+		if err != nil {
+			return
+		}
+		err = os.WriteFile(filepath.Join(directory, c.path), c.after, 0644)
+		if err != nil {
+			return
+		}
+		paths = append(paths, c.path)
+	}
+	return
+}
+
+// Print the diff of the changes instead of writing them, ErrWouldChange is returned if something would change
+func dryRun(changes []change) error {
+	changed, err := writeDiff(os.Stdout, changes)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Println("Nothing would change, same favorite music.")
+		return nil
+	}
+	return ErrWouldChange
+}
+
+// Add the paths (relative to the root of the working tree) to the index, returns whether one of them changed
+//
+// The other files of the working tree are not looked at, they can be modified by something else.
+func addToIndex(worktree *git.Worktree, paths []string) (changed bool, err error) {
+	for i := range paths {
+		paths[i] = filepath.ToSlash(paths[i])
+		_, err = worktree.Add(paths[i])
+		if err != nil {
+			return
+		}
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return
+	}
+	// Unmodified files are not inside of the status
+	for _, path := range paths {
+		if file, ok := status[path]; ok && file.Staging != git.Unmodified {
+			changed = true
+		}
+	}
+	return
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const TEST_README = "# Test\n<!-- FAVORITE_MUSIC_BADGE:START -->\nold\n<!-- FAVORITE_MUSIC_BADGE:END -->\n"
+
+var testSignature = object.Signature{Name: "Test", Email: "test@example.com"}
+
+// Create a bare repository with a first commit of the files, returns its path
+//
+// Local repositories are cloned and pushed with git-upload-pack and git-receive-pack, the test is
+// skipped without git.
+func newRemote(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git-upload-pack"); err != nil {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git is not installed")
+		}
+	}
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := git.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	seed := t.TempDir()
+	repo, err := git.PlainInit(seed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, repo, seed, files, "first commit")
+	if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{remote}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	return remote
+}
+
+// Write and commit the files inside of the working tree of repo
+func commitFiles(t *testing.T, repo *git.Repository, directory string, files map[string]string, message string) {
+	t.Helper()
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	signature := testSignature
+	signature.When = time.Now()
+	if _, err := worktree.Commit(message, &git.CommitOptions{Author: &signature}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Latest commit of the default branch of the repository
+func headCommit(t *testing.T, path string) *object.Commit {
+	t.Helper()
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commit
+}
+
+// Content of the file inside of the latest commit
+func commitFile(t *testing.T, commit *object.Commit, path string) string {
+	t.Helper()
+	file, err := commit.File(path)
+	if err != nil {
+		t.Fatalf("%v: %v", path, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// Clones of the repository left inside of the temporary directory
+func leftClones(t *testing.T, temporary string) []string {
+	t.Helper()
+	clones, err := filepath.Glob(filepath.Join(temporary, "favorite_music_badge-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clones
+}
+
+func testOptions() Options {
+	return Options{AuthorName: testSignature.Name, AuthorEmail: testSignature.Email}
+}
+
+func TestAddImageToRepository(t *testing.T) {
+	remote := newRemote(t, map[string]string{"README.md": TEST_README})
+	contents := map[string]string{"": "new"}
+	badge_files := map[string][]byte{"badges/badge.svg": []byte("<svg></svg>")}
+	options := testOptions()
+	options.CommitMessage = "update the badge"
+	options.Trailers = "[skip ci]"
+	// The clones are made inside of this directory
+	temporary := t.TempDir()
+	t.Setenv("TMPDIR", temporary)
+
+	err := AddImageToRepository(context.Background(), remote, "README.md", contents, badge_files, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := headCommit(t, remote)
+	if commit.Message != "update the badge\n\n[skip ci]" {
+		t.Errorf("Got the commit message %q", commit.Message)
+	}
+	if commit.Author.Email != testSignature.Email {
+		t.Errorf("Got the author %v", commit.Author)
+	}
+	if readme := commitFile(t, commit, "README.md"); !strings.Contains(readme, "-->\nnew\n<!--") {
+		t.Errorf("The badge wasn't added: %q", readme)
+	}
+	if svg := commitFile(t, commit, "badges/badge.svg"); svg != "<svg></svg>" {
+		t.Errorf("Got the badge file %q", svg)
+	}
+
+	// Nothing has changed, nothing is pushed
+	err = AddImageToRepository(context.Background(), remote, "README.md", contents, badge_files, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again := headCommit(t, remote); again.Hash != commit.Hash {
+		t.Errorf("Pushed the commit %v without any change", again.Hash)
+	}
+	if clones := leftClones(t, temporary); len(clones) != 0 {
+		t.Errorf("The clones %v weren't removed", clones)
+	}
+
+	options.KeepClone = true
+	err = AddImageToRepository(context.Background(), remote, "README.md", contents, badge_files, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clones := leftClones(t, temporary)
+	if len(clones) != 1 {
+		t.Fatalf("Got the clones %v with -keepClone, want one", clones)
+	}
+	if readme, err := os.ReadFile(filepath.Join(clones[0], "README.md")); err != nil || !strings.Contains(string(readme), "-->\nnew\n<!--") {
+		t.Errorf("The kept clone doesn't have the badge: %q %v", readme, err)
+	}
+}
+
+func TestAddImageToRepositoryDryRun(t *testing.T) {
+	remote := newRemote(t, map[string]string{"README.md": TEST_README})
+	before := headCommit(t, remote)
+	options := testOptions()
+	options.DryRun = true
+
+	err := AddImageToRepository(context.Background(), remote, "README.md", map[string]string{"": "new"}, nil, options)
+	if !errors.Is(err, ErrWouldChange) {
+		t.Errorf("Got %v, want ErrWouldChange", err)
+	}
+	err = AddImageToRepository(context.Background(), remote, "README.md", map[string]string{"": "old"}, nil, options)
+	if err != nil {
+		t.Errorf("Got %v without any change", err)
+	}
+	if after := headCommit(t, remote); after.Hash != before.Hash {
+		t.Error("The dry run pushed a commit")
+	}
+}
+
+func TestAddImageToRepositoryBranch(t *testing.T) {
+	remote := newRemote(t, map[string]string{"README.md": TEST_README})
+	before := headCommit(t, remote)
+	options := testOptions()
+	options.Branch = "missing"
+
+	err := AddImageToRepository(context.Background(), remote, "README.md", map[string]string{"": "new"}, nil, options)
+	if err == nil {
+		t.Error("Cloned a branch that doesn't exist")
+	}
+	if after := headCommit(t, remote); after.Hash != before.Hash {
+		t.Error("Pushed to the default branch")
+	}
+}
+
+func TestUpdateLocalRepository(t *testing.T) {
+	directory := t.TempDir()
+	repo, err := git.PlainInit(directory, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(directory, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, repo, directory, map[string]string{"docs/README.md": TEST_README, "other": "other"}, "first commit")
+	// Unrelated changes are not committed
+	if err := os.WriteFile(filepath.Join(directory, "other"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first := headCommit(t, directory)
+
+	// Without -localCommit the file is only written
+	docs := filepath.Join(directory, "docs")
+	err = UpdateLocalRepository(docs, "README.md", map[string]string{"": "new"}, nil, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headCommit(t, directory).Hash != first.Hash {
+		t.Error("Committed without options.Commit")
+	}
+
+	options := testOptions()
+	options.Commit = true
+	err = UpdateLocalRepository(docs, "README.md", map[string]string{"": "newer"}, map[string][]byte{"badge.svg": []byte("<svg></svg>")}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := headCommit(t, directory)
+	if commit.Hash == first.Hash || commit.Message != COMMIT_MESSAGE {
+		t.Fatalf("Got the commit %v %q", commit.Hash, commit.Message)
+	}
+	if readme := commitFile(t, commit, "docs/README.md"); !strings.Contains(readme, "newer") {
+		t.Errorf("The badge wasn't committed: %q", readme)
+	}
+	commitFile(t, commit, "docs/badge.svg")
+	if other := commitFile(t, commit, "other"); other != "other" {
+		t.Error("Committed an unrelated change")
+	}
+
+	// Nothing has changed, no commit
+	err = UpdateLocalRepository(docs, "README.md", map[string]string{"": "newer"}, map[string][]byte{"badge.svg": []byte("<svg></svg>")}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headCommit(t, directory).Hash != commit.Hash {
+		t.Error("Created a commit without any change")
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	var diff strings.Builder
+	changed, err := writeDiff(&diff, []change{
+		{path: "README.md", before: []byte("a\nb\n"), after: []byte("a\nc\n")},
+		{path: "same", before: []byte("same\n"), after: []byte("same\n")},
+		{path: "new.svg", after: []byte("<svg></svg>\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("The changes were not seen")
+	}
+	for _, want := range []string{"-b\n+c\n", "+<svg></svg>\n", "new.svg"} {
+		if !strings.Contains(diff.String(), want) {
+			t.Errorf("%q is missing from the diff:\n%v", want, diff.String())
+		}
+	}
+	if strings.Contains(diff.String(), "same") {
+		t.Errorf("The unchanged file is inside of the diff:\n%v", diff.String())
+	}
+
+	changed, err = writeDiff(&diff, []change{{path: "same", before: []byte("same\n"), after: []byte("same\n")}})
+	if err != nil || changed {
+		t.Errorf("Got %v and %v without any change", changed, err)
+	}
+}
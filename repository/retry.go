@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Delay before the first retry of a rejected push, doubled on every retry
+const PUSH_RETRY_DELAY = 2 * time.Second
+
+// Longest delay between two retries, whatever the number of retries
+const MAX_PUSH_RETRY_DELAY = time.Minute
+
+// Wrapped by the error of the push when someone else pushed to the branch since the clone
+var errPushRejected = errors.New("The push was rejected because the branch has changed.")
+
+// Delay before the first retry, replaced by the tests to not wait
+var pushRetryDelay = PUSH_RETRY_DELAY
+
+// Called right before pushing, replaced by the tests to push something else in the meantime
+var beforePush = func() {}
+
+// Whether the push was rejected because the branch has changed since it was cloned
+//
+// go-git checks that the push is a fast forward: with a shallow clone, the new commit of the
+// branch isn't known and the check fails with plumbing.ErrObjectNotFound. The other rejections
+// are only given as messages, by go-git or by the server that refuses to update a branch that
+// moved during the push.
+func isPushRejected(err error) bool {
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return true
+	}
+	message := err.Error()
+	for _, rejected := range []string{"non-fast-forward", "fetch first", "stale info", "cannot lock ref", "failed to update ref"} {
+		if strings.Contains(message, rejected) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay before that retry (starting at 1), at most MAX_PUSH_RETRY_DELAY
+func retryDelay(attempt int) time.Duration {
+	delay := pushRetryDelay
+	for range attempt - 1 {
+		delay *= 2
+		if delay >= MAX_PUSH_RETRY_DELAY {
+			return MAX_PUSH_RETRY_DELAY
+		}
+	}
+	return delay
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Push a commit of the files to the remote, like someone else would
+func pushToRemote(t *testing.T, remote string, files map[string]string) {
+	t.Helper()
+	directory := t.TempDir()
+	repo, err := git.PlainClone(directory, false, &git.CloneOptions{URL: remote})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, repo, directory, files, "someone else")
+	if err := repo.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Push to the remote between the clone and the push of the first attempts, the retries don't wait
+func pushBeforePush(t *testing.T, remote string, times int) {
+	pushRetryDelay = 0
+	t.Cleanup(func() { pushRetryDelay = PUSH_RETRY_DELAY })
+	pushed := 0
+	beforePush = func() {
+		if pushed < times {
+			pushed++
+			pushToRemote(t, remote, map[string]string{fmt.Sprintf("other-%v", pushed): "other"})
+		}
+	}
+	t.Cleanup(func() { beforePush = func() {} })
+}
+
+func TestAddImageToRepositoryRetry(t *testing.T) {
+	remote := newRemote(t, map[string]string{"README.md": TEST_README})
+	pushBeforePush(t, remote, 1)
+	options := testOptions()
+	options.PushRetries = 1
+
+	err := AddImageToRepository(context.Background(), remote, "README.md", map[string]string{"": "new"}, nil, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := headCommit(t, remote)
+	if readme := commitFile(t, commit, "README.md"); !strings.Contains(readme, "new") {
+		t.Errorf("The badge wasn't added: %q", readme)
+	}
+	// The badge is on top of the other commit
+	commitFile(t, commit, "other-1")
+	parent, err := commit.Parent(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.Message != "someone else" {
+		t.Errorf("Got the parent %q, want the other commit", parent.Message)
+	}
+}
+
+func TestAddImageToRepositoryNoRetry(t *testing.T) {
+	remote := newRemote(t, map[string]string{"README.md": TEST_README})
+	pushBeforePush(t, remote, 1)
+
+	err := AddImageToRepository(context.Background(), remote, "README.md", map[string]string{"": "new"}, nil, testOptions())
+	if !errors.Is(err, errPushRejected) {
+		t.Fatalf("Got %v, want the push to be rejected", err)
+	}
+	if readme := commitFile(t, headCommit(t, remote), "README.md"); readme != TEST_README {
+		t.Errorf("The badge was pushed: %q", readme)
+	}
+}
+
+func TestIsPushRejected(t *testing.T) {
+	tests := []struct {
+		err      error
+		rejected bool
+	}{
+		{plumbing.ErrObjectNotFound, true},
+		{fmt.Errorf("While pushing: %w", plumbing.ErrObjectNotFound), true},
+		{errors.New("non-fast-forward update: refs/heads/main"), true},
+		{errors.New("! [rejected] main -> main (fetch first)"), true},
+		{errors.New("command error on refs/heads/main: failed to update ref"), true},
+		{errors.New("cannot lock ref 'refs/heads/main'"), true},
+		// Only the error value of go-git, not a message about another object
+		{errors.New("object not found: the remote helper is missing"), false},
+		{errors.New("authentication required"), false},
+		{errors.New("repository not found"), false},
+		{context.Canceled, false},
+	}
+	for _, test := range tests {
+		if rejected := isPushRejected(test.err); rejected != test.rejected {
+			t.Errorf("%v: got %v, want %v", test.err, rejected, test.rejected)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{1, PUSH_RETRY_DELAY},
+		{2, 2 * PUSH_RETRY_DELAY},
+		{3, 4 * PUSH_RETRY_DELAY},
+		{10, MAX_PUSH_RETRY_DELAY},
+		// Would overflow without the maximum
+		{100, MAX_PUSH_RETRY_DELAY},
+		{1 << 30, MAX_PUSH_RETRY_DELAY},
+	}
+	for _, test := range tests {
+		if delay := retryDelay(test.attempt); delay != test.delay {
+			t.Errorf("Attempt %v: got %v, want %v", test.attempt, delay, test.delay)
+		}
+	}
+}
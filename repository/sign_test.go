@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/hiddeco/sshsig"
+	"golang.org/x/crypto/ssh"
+)
+
+// Generate an OpenPGP key, returns the armored private key and the keyring that checks the signatures
+func newOpenPGPKey(t *testing.T, passphrase string) (private_key string, keyring openpgp.EntityList) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test", "", testSignature.Email, &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passphrase != "" {
+		if err := entity.EncryptPrivateKeys([]byte(passphrase), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var key bytes.Buffer
+	writer, err := armor.Encode(&key, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The identities are already signed, an encrypted key can't sign them again
+	if err := entity.SerializePrivateWithoutSigning(writer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return key.String(), openpgp.EntityList{entity}
+}
+
+// Generate an ed25519 SSH key, returns the private key in the OpenSSH format and the public key
+func newSSHKey(t *testing.T, passphrase string) (private_key string, public_key ssh.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(private, "", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(private, "")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	public_key, err = ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(block)), public_key
+}
+
+// Push a signed commit to a new remote, returns the commit without its signature and the signature
+func signedCommit(t *testing.T, options Options) (signed io.Reader, signature string) {
+	t.Helper()
+	remote := newRemote(t, map[string]string{"README.md": TEST_README})
+	err := AddImageToRepository(context.Background(), remote, "README.md", map[string]string{"": "new"}, nil, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := headCommit(t, remote)
+	if commit.PGPSignature == "" {
+		t.Fatal("The commit isn't signed")
+	}
+	encoded := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader, commit.PGPSignature
+}
+
+func TestSignOpenPGP(t *testing.T) {
+	for _, passphrase := range []string{"", "secret"} {
+		key, keyring := newOpenPGPKey(t, passphrase)
+		options := testOptions()
+		options.SigningKey = key
+		options.SigningKeyPassphrase = passphrase
+
+		signed, signature := signedCommit(t, options)
+		if _, err := openpgp.CheckArmoredDetachedSignature(keyring, signed, strings.NewReader(signature), nil); err != nil {
+			t.Errorf("Passphrase %q: invalid signature: %v", passphrase, err)
+		}
+	}
+}
+
+func TestSignSSH(t *testing.T) {
+	for _, passphrase := range []string{"", "secret"} {
+		key, public_key := newSSHKey(t, passphrase)
+		options := testOptions()
+		options.SigningKey = key
+		options.SigningKeyPassphrase = passphrase
+
+		signed, armored := signedCommit(t, options)
+		signature, err := sshsig.Unarmor([]byte(armored))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sshsig.Verify(signed, signature, public_key, signature.HashAlgorithm, "git"); err != nil {
+			t.Errorf("Passphrase %q: invalid signature: %v", passphrase, err)
+		}
+	}
+}
+
+func TestSignerErrors(t *testing.T) {
+	pgp_key, _ := newOpenPGPKey(t, "secret")
+	ssh_key, _ := newSSHKey(t, "secret")
+	tests := []struct {
+		name       string
+		key        string
+		passphrase string
+	}{
+		{"encrypted OpenPGP key without passphrase", pgp_key, ""},
+		{"OpenPGP key with the wrong passphrase", pgp_key, "wrong"},
+		{"encrypted SSH key without passphrase", ssh_key, ""},
+		{"SSH key with the wrong passphrase", ssh_key, "wrong"},
+		{"not a key", "not a key", ""},
+	}
+	for _, test := range tests {
+		options := Options{SigningKey: test.key, SigningKeyPassphrase: test.passphrase}
+		if signer, err := options.signer(); err == nil {
+			t.Errorf("%v: got the signer %v without error", test.name, signer)
+		}
+	}
+
+	// Without a key the commits are not signed
+	if signer, err := (Options{}).signer(); signer != nil || err != nil {
+		t.Errorf("Got %v and %v without a key", signer, err)
+	}
+}